@@ -2,6 +2,7 @@ package gochimp3
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,6 +35,7 @@ type API struct {
 	Debug bool
 
 	endpoint string
+	ctx      context.Context
 }
 
 // New creates a API
@@ -50,6 +52,14 @@ func New(apiKey string) *API {
 	}
 }
 
+// WithContext returns a copy of the API whose requests are bound to ctx, so
+// they are aborted when ctx is cancelled.
+func (api *API) WithContext(ctx context.Context) *API {
+	clone := *api
+	clone.ctx = ctx
+	return &clone
+}
+
 // Request will make a call to the actual API.
 func (api *API) Request(method, path string, params QueryParams, body, response interface{}) error {
 	client := &http.Client{Transport: api.Transport}
@@ -80,6 +90,9 @@ func (api *API) Request(method, path string, params QueryParams, body, response
 	if err != nil {
 		return err
 	}
+	if api.ctx != nil {
+		req = req.WithContext(api.ctx)
+	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.SetBasicAuth(api.User, api.Key)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -37,6 +38,21 @@ func testAPI() *API {
 	return api
 }
 
+// testAPIServer returns an API talking to a fake Mailchimp served by
+// handler, the server must be closed by the caller.
+func testAPIServer(handler http.Handler) (*API, *httptest.Server) {
+	server := httptest.NewServer(handler)
+
+	api := New("apikey-us1")
+	api.endpoint = server.URL
+	return api, server
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
 func TestGoodGet(t *testing.T) {
 	expected := map[string]interface{}{
 		"one": "thing",
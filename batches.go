@@ -1,13 +1,27 @@
 package gochimp3
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 const (
 	batches_path      = "/batches"
 	single_batch_path = batches_path + "/%s"
+
+	BATCH_STATUS_PENDING       = "pending"
+	BATCH_STATUS_PREPROCESSING = "preprocessing"
+	BATCH_STATUS_STARTED       = "started"
+	BATCH_STATUS_FINALIZING    = "finalizing"
+	BATCH_STATUS_FINISHED      = "finished"
 )
 
 func (api *API) GetBatchOperations(params *ListQueryParams) (*ListOfBatchOperations, error) {
@@ -18,8 +32,8 @@ func (api *API) GetBatchOperations(params *ListQueryParams) (*ListOfBatchOperati
 		return nil, err
 	}
 
-	for _, l := range response.BatchOperations {
-		l.api = api
+	for i := range response.BatchOperations {
+		response.BatchOperations[i].api = api
 	}
 
 	return response, nil
@@ -72,3 +86,108 @@ type BatchOperation struct {
 
 	api *API
 }
+
+// BatchOperationResult is the outcome of one operation of a finished batch.
+// Response is the raw JSON body Mailchimp answered the operation with.
+type BatchOperationResult struct {
+	StatusCode  int    `json:"status_code"`
+	OperationID string `json:"operation_id"`
+	Response    string `json:"response"`
+}
+
+// Succeeded checks if the operation got a 2xx response
+func (result *BatchOperationResult) Succeeded() bool {
+	return result.StatusCode >= 200 && result.StatusCode < 300
+}
+
+// Error returns the API error of a failed operation
+func (result *BatchOperationResult) Error() error {
+	if result.Succeeded() {
+		return nil
+	}
+
+	apiError := new(APIError)
+	if err := json.Unmarshal([]byte(result.Response), apiError); err != nil || apiError.Status == 0 {
+		return fmt.Errorf("Operation %s failed with status %d", result.OperationID, result.StatusCode)
+	}
+	return apiError
+}
+
+// ErrBatchTimeout is returned by Wait when the batch did not finish in time
+var ErrBatchTimeout = errors.New("The batch operation did not finish in time")
+
+// Wait polls the batch every interval until it is finished. It returns
+// ErrBatchTimeout once timeout elapsed with the batch still running, zero
+// waits without limit.
+func (batch *BatchOperationResponse) Wait(interval, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for batch.Status != BATCH_STATUS_FINISHED {
+		if timeout > 0 && time.Now().Add(interval).After(deadline) {
+			return ErrBatchTimeout
+		}
+		time.Sleep(interval)
+
+		current, err := batch.api.GetBatchOperation(batch.ID, nil)
+		if err != nil {
+			return err
+		}
+		*batch = *current
+	}
+	return nil
+}
+
+// GetResults downloads the results of the operations of a finished batch
+func (batch *BatchOperationResponse) GetResults() ([]BatchOperationResult, error) {
+	if batch.Status != BATCH_STATUS_FINISHED || batch.ResponseBodyUrl == "" {
+		return nil, errors.New("The batch operation is not finished")
+	}
+
+	client := &http.Client{Transport: batch.api.Transport}
+	if batch.api.Timeout > 0 {
+		client.Timeout = batch.api.Timeout
+	}
+
+	resp, err := client.Get(batch.ResponseBodyUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Downloading the batch results failed with status %d", resp.StatusCode)
+	}
+
+	return readBatchResults(resp.Body)
+}
+
+// readBatchResults reads the gzipped tar archive of JSON files Mailchimp
+// stores the results of a batch in.
+func readBatchResults(r io.Reader) ([]BatchOperationResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	results := []BatchOperationResult{}
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, ".json") {
+			continue
+		}
+
+		var page []BatchOperationResult
+		if err := json.NewDecoder(archive).Decode(&page); err != nil {
+			return nil, err
+		}
+		results = append(results, page...)
+	}
+}
@@ -32,6 +32,12 @@ func (err *APIError) HasError() bool {
 	return err.Type != ""
 }
 
+// isNotFound checks if err is the API telling the resource does not exist
+func isNotFound(err error) bool {
+	apiError, ok := err.(*APIError)
+	return ok && apiError.Status == 404
+}
+
 // QueryParams defines the different params
 type QueryParams interface {
 	Params() map[string]string
@@ -52,6 +58,31 @@ func (q *ExtendedQueryParams) Params() map[string]string {
 	return m
 }
 
+// Mailchimp caps the page size of collection endpoints at 1000 items
+const maxPageSize = 1000
+
+// pageThrough calls fetch until the last page of a collection, starting at
+// params.Offset with pages of params.Count items, maxPageSize when unset.
+// fetch requests the page at params and returns the number of items on it
+// and the total number of items. It stops at the first error.
+func pageThrough(params *ExtendedQueryParams, fetch func() (int, int, error)) error {
+	if params.Count <= 0 {
+		params.Count = maxPageSize
+	}
+
+	for {
+		n, total, err := fetch()
+		if err != nil {
+			return err
+		}
+
+		params.Offset += n
+		if n < params.Count || params.Offset >= total {
+			return nil
+		}
+	}
+}
+
 // BasicQueryParams basic filter queries
 type BasicQueryParams struct {
 	Status        string
@@ -149,3 +180,18 @@ type Contact struct {
 	Country     string `json:"country"`
 	PhoneNumber string `json:"phone"`
 }
+
+// Bool returns a pointer to v, for the optional fields of update requests
+func Bool(v bool) *bool {
+	return &v
+}
+
+// Int returns a pointer to v, for the optional fields of update requests
+func Int(v int) *int {
+	return &v
+}
+
+// String returns a pointer to v, for the optional fields of update requests
+func String(v string) *string {
+	return &v
+}
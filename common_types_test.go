@@ -0,0 +1,38 @@
+package gochimp3
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageThrough(t *testing.T) {
+	// pages of a collection of 5 items, starting at an offset
+	params := &ExtendedQueryParams{Count: 2, Offset: 2}
+	var offsets []int
+	fatalIf(t, pageThrough(params, func() (int, int, error) {
+		offsets = append(offsets, params.Offset)
+		n := 5 - params.Offset
+		if n > params.Count {
+			n = params.Count
+		}
+		return n, 5, nil
+	}))
+	assert.Equal(t, []int{2, 4}, offsets)
+
+	// full pages stop at the total
+	params = &ExtendedQueryParams{}
+	calls := 0
+	fatalIf(t, pageThrough(params, func() (int, int, error) {
+		calls++
+		return params.Count, 2 * maxPageSize, nil
+	}))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, maxPageSize, params.Count)
+
+	failed := errors.New("failed")
+	assert.Equal(t, failed, pageThrough(&ExtendedQueryParams{}, func() (int, int, error) {
+		return 0, 0, failed
+	}))
+}
@@ -88,6 +88,10 @@ func (api *API) GetStores(params *ExtendedQueryParams) (*StoreList, error) {
 		return nil, err
 	}
 
+	for i := range response.Stores {
+		response.Stores[i].api = api
+	}
+
 	return response, nil
 }
 
@@ -138,12 +142,25 @@ func (api *API) DeleteStore(id string) (bool, error) {
 type CustomerList struct {
 	APIError
 
-	Customers  []Customer `json:"customer"`
+	Customers  []Customer `json:"customers"`
 	TotalItems int        `json:"total_items"`
 	Links      []Link     `json:"_links"`
 }
 
-func (store *Store) GetCustomers(params *ExtendedQueryParams) (*CustomerList, error) {
+type CustomerQueryParams struct {
+	ExtendedQueryParams
+
+	EmailAddress string
+}
+
+func (q *CustomerQueryParams) Params() map[string]string {
+	m := q.ExtendedQueryParams.Params()
+	m["email_address"] = q.EmailAddress
+	return m
+}
+
+// GetCustomers accepts *ExtendedQueryParams or *CustomerQueryParams
+func (store *Store) GetCustomers(params QueryParams) (*CustomerList, error) {
 	response := new(CustomerList)
 
 	if store.HasError() {
@@ -323,11 +340,25 @@ func (store *Store) DeleteCart(id string) (bool, error) {
 type OrderList struct {
 	APIError
 
-	Orders     []Order `json:"cart"`
+	Orders     []Order `json:"orders"`
 	TotalItems int     `json:"total_items"`
 	Links      []Link  `json:"_links,omitempty"`
 }
 
+type OrderQueryParams struct {
+	ExtendedQueryParams
+
+	CustomerID string
+	CampaignID string
+}
+
+func (q *OrderQueryParams) Params() map[string]string {
+	m := q.ExtendedQueryParams.Params()
+	m["customer_id"] = q.CustomerID
+	m["campaign_id"] = q.CampaignID
+	return m
+}
+
 type Order struct {
 	APIError
 
@@ -358,13 +389,14 @@ type Order struct {
 	Links     []Link    `json:"_links,omitempty"`
 }
 
-func (store *Store) GetOrders(params *ExtendedQueryParams) (*OrderList, error) {
+// GetOrders accepts *ExtendedQueryParams or *OrderQueryParams
+func (store *Store) GetOrders(params QueryParams) (*OrderList, error) {
 	response := new(OrderList)
 
 	if store.HasError() {
 		return nil, fmt.Errorf("The store has an error, can't process request")
 	}
-	endpoint := fmt.Sprintf(carts_path, store.ID)
+	endpoint := fmt.Sprintf(orders_path, store.ID)
 	err := store.api.Request("GET", endpoint, params, nil, response)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,321 @@
+package gochimp3
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultEngagementConcurrency = 4
+	defaultRecencyHalfLife       = 30 * 24 * time.Hour
+	defaultSpendScale            = 100
+	maxMemberRating              = 5
+)
+
+// EngagementWeights weighs the components of an engagement score. Every
+// component is between 0 and 1, a zero weight skips the component and the
+// requests it needs.
+type EngagementWeights struct {
+	OpenRate  float64 `json:"open_rate"`
+	ClickRate float64 `json:"click_rate"`
+	Rating    float64 `json:"rating"`
+	Recency   float64 `json:"recency"`
+	Spend     float64 `json:"spend"`
+}
+
+var DefaultEngagementWeights = EngagementWeights{
+	OpenRate:  0.3,
+	ClickRate: 0.3,
+	Rating:    0.1,
+	Recency:   0.2,
+	Spend:     0.1,
+}
+
+func (weights *EngagementWeights) total() float64 {
+	return weights.OpenRate + weights.ClickRate + weights.Rating + weights.Recency + weights.Spend
+}
+
+// EngagementModel turns what is known of a member into a score from 0 to 100
+type EngagementModel struct {
+	Weights EngagementWeights
+
+	// RecencyHalfLife is the time after which the recency of the last open
+	// or click counts half.
+	RecencyHalfLife time.Duration
+
+	// SpendScale is the total spent counting half in the spend component.
+	SpendScale float64
+}
+
+func NewEngagementModel() *EngagementModel {
+	return &EngagementModel{
+		Weights:         DefaultEngagementWeights,
+		RecencyHalfLife: defaultRecencyHalfLife,
+		SpendScale:      defaultSpendScale,
+	}
+}
+
+// EngagementInput is what a member is scored on. LastEngagedAt is zero for a
+// member who never opened or clicked.
+type EngagementInput struct {
+	Member        *Member
+	LastEngagedAt time.Time
+	TotalSpent    float64
+}
+
+// Score computes the engagement score of input at now
+func (model *EngagementModel) Score(input *EngagementInput, now time.Time) float64 {
+	weights := model.Weights
+	total := weights.total()
+	if total <= 0 {
+		return 0
+	}
+
+	score := weights.OpenRate*clamp01(input.Member.Stats.AvgOpenRate) +
+		weights.ClickRate*clamp01(input.Member.Stats.AvgClickRate) +
+		weights.Rating*clamp01(float64(input.Member.MemberRating)/maxMemberRating)
+
+	if !input.LastEngagedAt.IsZero() {
+		halfLife := model.RecencyHalfLife
+		if halfLife <= 0 {
+			halfLife = defaultRecencyHalfLife
+		}
+		elapsed := math.Max(0, float64(now.Sub(input.LastEngagedAt)))
+		score += weights.Recency * math.Pow(0.5, elapsed/float64(halfLife))
+	}
+
+	if input.TotalSpent > 0 {
+		scale := model.SpendScale
+		if scale <= 0 {
+			scale = defaultSpendScale
+		}
+		score += weights.Spend * input.TotalSpent / (input.TotalSpent + scale)
+	}
+
+	return math.Round(1000*score/total) / 10
+}
+
+func clamp01(x float64) float64 {
+	return math.Min(1, math.Max(0, x))
+}
+
+// EngagementTier tags the members scoring at least MinScore
+type EngagementTier struct {
+	Tag      string  `json:"tag"`
+	MinScore float64 `json:"min_score"`
+}
+
+// EngagementScorer scores the members of a list and writes the scores back
+// to a number merge field, tier tags, or both.
+type EngagementScorer struct {
+	List  *ListResponse
+	Model *EngagementModel
+
+	// Params selects the members to score, all of them when nil.
+	Params *MemberQueryParams
+
+	// Stores are searched for customers with the email of the member to
+	// sum their TotalSpent.
+	Stores []Store
+
+	// MergeField is the tag of the number merge field the score is written
+	// to, if any.
+	MergeField string
+
+	// Tiers are the tags set from the score: the member gets the tag of the
+	// highest tier it reaches and loses the tags of the other tiers.
+	Tiers []EngagementTier
+
+	Concurrency int
+
+	// DryRun scores the members without updating them
+	DryRun bool
+}
+
+func (list *ListResponse) NewEngagementScorer(model *EngagementModel) *EngagementScorer {
+	return &EngagementScorer{
+		List:        list,
+		Model:       model,
+		Concurrency: defaultEngagementConcurrency,
+	}
+}
+
+type EngagementScore struct {
+	EmailAddress string  `json:"email_address"`
+	MemberID     string  `json:"member_id"`
+	Score        float64 `json:"score"`
+	Tier         string  `json:"tier,omitempty"`
+	Updated      bool    `json:"updated"`
+}
+
+type EngagementFailure struct {
+	EmailAddress string `json:"email_address"`
+	Error        string `json:"error"`
+}
+
+// EngagementReport lists the scores by email address
+type EngagementReport struct {
+	DryRun   bool                `json:"dry_run"`
+	Scores   []EngagementScore   `json:"scores"`
+	Updated  int                 `json:"updated"`
+	Failures []EngagementFailure `json:"failures,omitempty"`
+}
+
+// Run scores every member with Concurrency members handled at a time. A
+// failure on a member is reported and does not stop the run, an error
+// listing the members does.
+func (scorer *EngagementScorer) Run() (*EngagementReport, error) {
+	if err := scorer.List.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	model := scorer.Model
+	if model == nil {
+		model = NewEngagementModel()
+	}
+	concurrency := scorer.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultEngagementConcurrency
+	}
+
+	report := &EngagementReport{DryRun: scorer.DryRun, Scores: []EngagementScore{}}
+	now := time.Now()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	members := make(chan Member)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for member := range members {
+				score, err := scorer.scoreMember(model, &member, now)
+
+				mu.Lock()
+				if err != nil {
+					report.Failures = append(report.Failures, EngagementFailure{
+						EmailAddress: member.EmailAddress,
+						Error:        err.Error(),
+					})
+				} else {
+					report.Scores = append(report.Scores, *score)
+					if score.Updated {
+						report.Updated++
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := scorer.List.EachMember(scorer.Params, func(member *Member) error {
+		members <- *member
+		return nil
+	})
+	close(members)
+	wg.Wait()
+
+	sort.Slice(report.Scores, func(i, j int) bool {
+		return report.Scores[i].EmailAddress < report.Scores[j].EmailAddress
+	})
+	return report, err
+}
+
+func (scorer *EngagementScorer) scoreMember(model *EngagementModel, member *Member, now time.Time) (*EngagementScore, error) {
+	input := &EngagementInput{Member: member}
+
+	if model.Weights.Recency > 0 {
+		last, err := member.LastEngagedAt()
+		if err != nil {
+			return nil, err
+		}
+		input.LastEngagedAt = last
+	}
+
+	if model.Weights.Spend > 0 {
+		for i := range scorer.Stores {
+			customers, err := scorer.Stores[i].getCustomersByEmail(member.EmailAddress)
+			if err != nil {
+				return nil, err
+			}
+			for _, customer := range customers {
+				input.TotalSpent += customer.TotalSpent
+			}
+		}
+	}
+
+	score := &EngagementScore{
+		EmailAddress: member.EmailAddress,
+		MemberID:     member.ID,
+		Score:        model.Score(input, now),
+	}
+	score.Tier = scorer.tier(score.Score)
+
+	if scorer.DryRun {
+		return score, nil
+	}
+
+	updated, err := scorer.writeBack(member, score)
+	score.Updated = updated
+	return score, err
+}
+
+// tier returns the tag of the highest tier reached by score
+func (scorer *EngagementScorer) tier(score float64) string {
+	tag := ""
+	best := math.Inf(-1)
+	for _, tier := range scorer.Tiers {
+		if score >= tier.MinScore && tier.MinScore > best {
+			tag, best = tier.Tag, tier.MinScore
+		}
+	}
+	return tag
+}
+
+func (scorer *EngagementScorer) writeBack(member *Member, score *EngagementScore) (bool, error) {
+	updated := false
+
+	if scorer.MergeField != "" {
+		current, err := member.MergeFields.GetNumber(scorer.MergeField)
+		if err != nil || current != score.Score {
+			fields := MergeFields{}
+			fields.SetNumber(scorer.MergeField, score.Score)
+
+			if err := scorer.List.updateMemberMergeFields(member.ID, fields); err != nil {
+				return false, err
+			}
+			updated = true
+		}
+	}
+
+	if changes := scorer.tierChanges(member, score.Tier); len(changes) > 0 {
+		if _, err := member.UpdateTags(changes); err != nil {
+			return updated, err
+		}
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// tierChanges returns the tag updates giving the member only the tag of tier
+func (scorer *EngagementScorer) tierChanges(member *Member, tier string) []UpdateMemberTag {
+	current := make(map[string]bool, len(member.Tags))
+	for _, tag := range member.Tags {
+		current[tag.Name] = true
+	}
+
+	var changes []UpdateMemberTag
+	for _, t := range scorer.Tiers {
+		switch {
+		case t.Tag == tier && !current[t.Tag]:
+			changes = append(changes, UpdateMemberTag{Name: t.Tag, Status: MEMBER_TAG_ACTIVE})
+		case t.Tag != tier && current[t.Tag]:
+			changes = append(changes, UpdateMemberTag{Name: t.Tag, Status: MEMBER_TAG_INACTIVE})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,93 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngagementModelScore(t *testing.T) {
+	now := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	model := NewEngagementModel()
+
+	member := &Member{MemberRating: 5, Stats: MemberStats{AvgOpenRate: 1, AvgClickRate: 1}}
+	assert.Equal(t, 99.0, model.Score(&EngagementInput{Member: member, LastEngagedAt: now, TotalSpent: 900}, now))
+
+	halfLife := now.Add(-model.RecencyHalfLife)
+	assert.Equal(t, 10.0, model.Score(&EngagementInput{Member: &Member{}, LastEngagedAt: halfLife}, now))
+	assert.Equal(t, 0.0, model.Score(&EngagementInput{Member: &Member{}}, now))
+}
+
+func TestEngagementScorer(t *testing.T) {
+	ada := SubscriberHash("ada@example.com")
+	bob := SubscriberHash("bob@example.com")
+
+	var mu sync.Mutex
+	updates := map[string]interface{}{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/members", func(w http.ResponseWriter, r *http.Request) {
+		members := &ListOfMembers{Members: []Member{
+			{ID: ada, ListID: "list1", MemberRating: 5, Stats: MemberStats{AvgOpenRate: 1, AvgClickRate: 1}},
+			{ID: bob, ListID: "list1", MemberRating: 1},
+		}}
+		members.Members[0].EmailAddress = "ada@example.com"
+		members.Members[0].Status = MEMBER_STATUS_SUBSCRIBED
+		members.Members[1].EmailAddress = "bob@example.com"
+		members.Members[1].Status = MEMBER_STATUS_SUBSCRIBED
+		members.Members[1].Tags = []MemberTag{{ID: 1, Name: "engaged"}}
+		members.TotalItems = 2
+		writeJSON(w, members)
+	})
+	mux.HandleFunc("/lists/list1/members/", func(w http.ResponseWriter, r *http.Request) {
+		var body interface{}
+		if r.Method != "GET" {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+
+		mu.Lock()
+		updates[r.Method+" "+strings.TrimPrefix(r.URL.Path, "/lists/list1/members/")] = body
+		mu.Unlock()
+		writeJSON(w, map[string]interface{}{})
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	model := NewEngagementModel()
+	model.Weights.Recency = 0
+	model.Weights.Spend = 0
+
+	scorer := api.NewListResponse("list1").NewEngagementScorer(model)
+	scorer.MergeField = "SCORE"
+	scorer.Tiers = []EngagementTier{{Tag: "engaged", MinScore: 50}, {Tag: "dormant", MinScore: 0}}
+	scorer.DryRun = true
+
+	report, err := scorer.Run()
+	fatalIf(t, err)
+	assert.Empty(t, updates)
+	if assert.Len(t, report.Scores, 2) {
+		assert.Equal(t, EngagementScore{EmailAddress: "ada@example.com", MemberID: ada, Score: 100, Tier: "engaged"}, report.Scores[0])
+		assert.Equal(t, "dormant", report.Scores[1].Tier)
+	}
+
+	scorer.DryRun = false
+	report, err = scorer.Run()
+	fatalIf(t, err)
+	assert.Equal(t, 2, report.Updated)
+	assert.Equal(t, map[string]interface{}{
+		"merge_fields": map[string]interface{}{"SCORE": 100.0},
+	}, updates["PATCH "+ada])
+	assert.Equal(t, map[string]interface{}{"tags": []interface{}{
+		map[string]interface{}{"name": "engaged", "status": MEMBER_TAG_ACTIVE},
+	}}, updates["POST "+ada+"/tags"])
+	assert.Equal(t, map[string]interface{}{"tags": []interface{}{
+		map[string]interface{}{"name": "engaged", "status": MEMBER_TAG_INACTIVE},
+		map[string]interface{}{"name": "dormant", "status": MEMBER_TAG_ACTIVE},
+	}}, updates["POST "+bob+"/tags"])
+}
@@ -0,0 +1,199 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultEventBatchSize     = 1000
+	defaultEventFlushInterval = 10 * time.Second
+
+	// defaultEventMaxPending is the default cap of buffered events in batches
+	defaultEventMaxPending = 10
+)
+
+// EventEmitter buffers member events from any number of goroutines and sends
+// them in bulk through the Batch Operations endpoint, in the background when
+// batchSize events are pending and every interval. After a failed flush the
+// events are only sent again on the next interval.
+type EventEmitter struct {
+	// Schemas validates events by name before they are buffered. Events
+	// without a schema are only rejected when Strict is set.
+	Schemas map[string]*EventSchema
+	Strict  bool
+
+	// OnFlush is called with the outcome of every submitted batch, flushes
+	// in the background have no other way to report errors.
+	OnFlush func(*BatchOperationResponse, error)
+
+	// MaxPending caps the buffered events, Emit rejects events once it is
+	// reached, e.g. while the API is down. It defaults to 10 batches.
+	MaxPending int
+
+	api       *API
+	batchSize int
+
+	mu      sync.Mutex
+	pending []BatchOperation
+	closed  bool
+	failing bool
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewEventEmitter starts an emitter, it must be closed to send the last events.
+// A zero batchSize or interval uses the defaults of 1000 events and 10s.
+func (api *API) NewEventEmitter(batchSize int, interval time.Duration) *EventEmitter {
+	if batchSize <= 0 {
+		batchSize = defaultEventBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultEventFlushInterval
+	}
+
+	emitter := &EventEmitter{
+		MaxPending: defaultEventMaxPending * batchSize,
+		api:        api,
+		batchSize:  batchSize,
+		flush:      make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go emitter.loop(interval)
+	return emitter
+}
+
+// AddSchema registers the schema events named schema.Name are validated with
+func (emitter *EventEmitter) AddSchema(schema *EventSchema) {
+	emitter.mu.Lock()
+	defer emitter.mu.Unlock()
+
+	if emitter.Schemas == nil {
+		emitter.Schemas = make(map[string]*EventSchema)
+	}
+	emitter.Schemas[schema.Name] = schema
+}
+
+// Emit validates the event and buffers it for the member of the list with the
+// given email. Once it returns nil the event is sent by a later flush, an
+// error means the event was not accepted.
+func (emitter *EventEmitter) Emit(listID, email string, e *EventRequest) error {
+	if err := ValidateEventName(e.Name); err != nil {
+		return err
+	}
+
+	emitter.mu.Lock()
+	schema, ok := emitter.Schemas[e.Name]
+	strict := emitter.Strict
+	emitter.mu.Unlock()
+
+	switch {
+	case ok:
+		if err := schema.Validate(e); err != nil {
+			return err
+		}
+	case strict:
+		return fmt.Errorf("No schema for event %s", e.Name)
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	operation := BatchOperation{
+		Method: "POST",
+		Path:   fmt.Sprintf(member_events_path, listID, SubscriberHash(email)),
+		Body:   string(body),
+	}
+
+	emitter.mu.Lock()
+	defer emitter.mu.Unlock()
+
+	if emitter.closed {
+		return errors.New("The event emitter is closed")
+	}
+	if emitter.MaxPending > 0 && len(emitter.pending) >= emitter.MaxPending {
+		return fmt.Errorf("The event emitter already buffers %d events", len(emitter.pending))
+	}
+	emitter.pending = append(emitter.pending, operation)
+
+	if len(emitter.pending) >= emitter.batchSize {
+		select {
+		case emitter.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush sends the buffered events right away. When the batch can't be created
+// the events are buffered again, ahead of the newer ones, for the next flush.
+func (emitter *EventEmitter) Flush() error {
+	emitter.mu.Lock()
+	operations := emitter.pending
+	emitter.pending = nil
+	emitter.mu.Unlock()
+
+	if len(operations) == 0 {
+		return nil
+	}
+
+	response, err := emitter.api.CreateBatchOperation(&BatchOperationCreationRequest{Operations: operations})
+	emitter.mu.Lock()
+	if err != nil {
+		emitter.pending = append(operations, emitter.pending...)
+	}
+	emitter.failing = err != nil
+	emitter.mu.Unlock()
+
+	if emitter.OnFlush != nil {
+		emitter.OnFlush(response, err)
+	}
+	return err
+}
+
+// Close stops the timer and flushes the remaining events
+func (emitter *EventEmitter) Close() error {
+	emitter.mu.Lock()
+	if emitter.closed {
+		emitter.mu.Unlock()
+		return nil
+	}
+	emitter.closed = true
+	emitter.mu.Unlock()
+
+	close(emitter.stop)
+	<-emitter.done
+	return emitter.Flush()
+}
+
+func (emitter *EventEmitter) loop(interval time.Duration) {
+	defer close(emitter.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			emitter.Flush()
+		case <-emitter.flush:
+			// back off until the next tick after a failure
+			emitter.mu.Lock()
+			failing := emitter.failing
+			emitter.mu.Unlock()
+			if !failing {
+				emitter.Flush()
+			}
+		case <-emitter.stop:
+			return
+		}
+	}
+}
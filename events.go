@@ -1,14 +1,25 @@
 package gochimp3
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"time"
 )
 
 const (
 	member_events_path = single_member_path + "/events"
+
+	EVENT_PROPERTY_STRING  EventPropertyType = "string"
+	EVENT_PROPERTY_NUMBER  EventPropertyType = "number"
+	EVENT_PROPERTY_BOOLEAN EventPropertyType = "boolean"
+	EVENT_PROPERTY_TIME    EventPropertyType = "time" // RFC 3339
 )
 
+// EventNameRegex is what Mailchimp accepts as an event name
+var EventNameRegex = regexp.MustCompile("^[A-Za-z0-9_-]{2,30}$")
+
 type EventRequest struct {
 	Name       string            `json:"name"`
 	Properties map[string]string `json:"properties,omitempty"`
@@ -16,6 +27,20 @@ type EventRequest struct {
 	OccurredAt *time.Time        `json:"occurred_at,omitempty"`
 }
 
+type ListOfMemberEvents struct {
+	baseList
+
+	Events []MemberEvent `json:"events"`
+}
+
+type MemberEvent struct {
+	Name       string            `json:"name"`
+	Properties map[string]string `json:"properties"`
+	OccurredAt string            `json:"occurred_at"`
+
+	withLinks
+}
+
 func (m *Member) AddEvent(e *EventRequest) error {
 	if err := m.CanMakeRequest(); err != nil {
 		return err
@@ -29,3 +54,89 @@ func (m *Member) AddEvent(e *EventRequest) error {
 func (m *Member) AddSimpleEvent(name string) error {
 	return m.AddEvent(&EventRequest{Name: name})
 }
+
+func (m *Member) GetEvents(params *ExtendedQueryParams) (*ListOfMemberEvents, error) {
+	if err := m.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(member_events_path, m.ListID, m.ID)
+	response := new(ListOfMemberEvents)
+
+	return response, m.api.Request("GET", endpoint, params, nil, response)
+}
+
+// ------------------------------------------------------------------------------------------------
+// Schemas
+// ------------------------------------------------------------------------------------------------
+
+// EventPropertyType is the type of the string value of an event property
+type EventPropertyType string
+
+// EventSchema describes the properties of an event, to catch malformed
+// events before they are sent.
+type EventSchema struct {
+	Name       string
+	Properties map[string]EventPropertyType
+	Required   []string
+
+	// AllowUnknown accepts properties which are not in Properties.
+	AllowUnknown bool
+}
+
+// ValidateEventName checks name is accepted by Mailchimp as an event name
+func ValidateEventName(name string) error {
+	if !EventNameRegex.MatchString(name) {
+		return fmt.Errorf("Invalid event name %q, it must be 2 to 30 letters, digits, - or _", name)
+	}
+	return nil
+}
+
+// Validate checks the event matches the schema
+func (schema *EventSchema) Validate(e *EventRequest) error {
+	if e.Name != schema.Name {
+		return fmt.Errorf("Event %s does not match schema %s", e.Name, schema.Name)
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := e.Properties[name]; !ok {
+			return fmt.Errorf("Event %s is missing property %s", e.Name, name)
+		}
+	}
+
+	for name, value := range e.Properties {
+		propertyType, ok := schema.Properties[name]
+		if !ok {
+			if schema.AllowUnknown {
+				continue
+			}
+			return fmt.Errorf("Event %s has unknown property %s", e.Name, name)
+		}
+
+		if err := propertyType.validate(value); err != nil {
+			return fmt.Errorf("Event %s property %s: %v", e.Name, name, err)
+		}
+	}
+
+	return nil
+}
+
+func (t EventPropertyType) validate(value string) error {
+	var err error
+	switch t {
+	case EVENT_PROPERTY_STRING:
+	case EVENT_PROPERTY_NUMBER:
+		_, err = strconv.ParseFloat(value, 64)
+	case EVENT_PROPERTY_BOOLEAN:
+		_, err = strconv.ParseBool(value)
+	case EVENT_PROPERTY_TIME:
+		_, err = time.Parse(time.RFC3339, value)
+	default:
+		err = errors.New("unknown property type " + string(t))
+	}
+
+	if err != nil {
+		return fmt.Errorf("%q is not a %s", value, t)
+	}
+	return nil
+}
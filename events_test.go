@@ -3,6 +3,8 @@ package gochimp3
 import (
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
 	"testing"
 	"time"
 )
@@ -36,4 +38,113 @@ func TestEventsJson(t *testing.T) {
 	assert.Equal(t, missingFields, backFromJson)
 	assert.Nil(t, backFromJson.Properties)
 	assert.Nil(t, backFromJson.OccurredAt)
-}
\ No newline at end of file
+}
+
+func TestEventSchemaValidate(t *testing.T) {
+	schema := &EventSchema{
+		Name: "purchased",
+		Properties: map[string]EventPropertyType{
+			"sku":    EVENT_PROPERTY_STRING,
+			"amount": EVENT_PROPERTY_NUMBER,
+			"gift":   EVENT_PROPERTY_BOOLEAN,
+		},
+		Required: []string{"sku"},
+	}
+
+	assert.NoError(t, schema.Validate(&EventRequest{Name: "purchased", Properties: map[string]string{"sku": "A1", "amount": "9.99", "gift": "true"}}))
+	assert.Error(t, schema.Validate(&EventRequest{Name: "purchased", Properties: map[string]string{"amount": "9.99"}}))
+	assert.Error(t, schema.Validate(&EventRequest{Name: "purchased", Properties: map[string]string{"sku": "A1", "amount": "cheap"}}))
+	assert.Error(t, schema.Validate(&EventRequest{Name: "purchased", Properties: map[string]string{"sku": "A1", "color": "red"}}))
+
+	assert.NoError(t, ValidateEventName("signed_up-2"))
+	assert.Error(t, ValidateEventName("x"))
+	assert.Error(t, ValidateEventName("signed up"))
+}
+
+func TestEventEmitter(t *testing.T) {
+	var mu sync.Mutex
+	var batches []BatchOperationCreationRequest
+	api, server := testAPIServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/batches", r.URL.Path)
+		body := BatchOperationCreationRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+
+		mu.Lock()
+		batches = append(batches, body)
+		mu.Unlock()
+		writeJSON(w, &BatchOperationResponse{ID: "batch", Status: "pending"})
+	}))
+	defer server.Close()
+
+	emitter := api.NewEventEmitter(100, time.Hour)
+	emitter.Strict = true
+	emitter.AddSchema(&EventSchema{Name: "clicked", AllowUnknown: true})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 5; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				assert.NoError(t, emitter.Emit("list1", "User@Example.com", &EventRequest{Name: "clicked"}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Error(t, emitter.Emit("list1", "user@example.com", &EventRequest{Name: "unknown"}))
+	fatalIf(t, emitter.Close())
+	assert.Error(t, emitter.Emit("list1", "user@example.com", &EventRequest{Name: "clicked"}))
+
+	total := 0
+	for _, batch := range batches {
+		total += len(batch.Operations)
+	}
+	assert.Equal(t, 250, total)
+	assert.Equal(t, "/lists/list1/members/b58996c504c5638798eb6b511e6f49af/events", batches[0].Operations[0].Path)
+	assert.Equal(t, `{"name":"clicked"}`, batches[0].Operations[0].Body)
+}
+
+func TestEventEmitterFlushFailure(t *testing.T) {
+	var mu sync.Mutex
+	var sent []BatchOperationCreationRequest
+	failed := false
+	api, server := testAPIServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !failed {
+			failed = true
+			http.Error(w, `{"status": 503, "title": "Service Unavailable"}`, 503)
+			return
+		}
+		body := BatchOperationCreationRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		sent = append(sent, body)
+		writeJSON(w, &BatchOperationResponse{ID: "batch", Status: "pending"})
+	}))
+	defer server.Close()
+
+	flushed := make(chan error, 2)
+	emitter := api.NewEventEmitter(2, time.Hour)
+	emitter.MaxPending = 3
+	emitter.OnFlush = func(response *BatchOperationResponse, err error) {
+		flushed <- err
+	}
+
+	// a full buffer is flushed in the background, the events are accepted
+	fatalIf(t, emitter.Emit("list1", "ada@example.com", &EventRequest{Name: "first"}))
+	fatalIf(t, emitter.Emit("list1", "ada@example.com", &EventRequest{Name: "second"}))
+	assert.Error(t, <-flushed)
+
+	// no new flush until the next tick, and no more than MaxPending events
+	fatalIf(t, emitter.Emit("list1", "ada@example.com", &EventRequest{Name: "third"}))
+	assert.Error(t, emitter.Emit("list1", "ada@example.com", &EventRequest{Name: "fourth"}))
+
+	fatalIf(t, emitter.Close())
+	assert.NoError(t, <-flushed)
+
+	if assert.Len(t, sent, 1) && assert.Len(t, sent[0].Operations, 3) {
+		assert.Equal(t, `{"name":"first"}`, sent[0].Operations[0].Body)
+		assert.Equal(t, `{"name":"third"}`, sent[0].Operations[2].Body)
+	}
+}
@@ -0,0 +1,513 @@
+package gochimp3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------------------------------------------
+// Data bundle
+// ------------------------------------------------------------------------------------------------
+
+// MemberDataBundle holds everything Mailchimp stores about an email address
+type MemberDataBundle struct {
+	EmailAddress   string              `json:"email_address"`
+	SubscriberHash string              `json:"subscriber_hash"`
+	ExportedAt     time.Time           `json:"exported_at"`
+	Lists          []ListMemberData    `json:"lists"`
+	Customers      []StoreCustomerData `json:"customers"`
+}
+
+// ListMemberData is the data of the email address on one list
+type ListMemberData struct {
+	ListID   string             `json:"list_id"`
+	ListName string             `json:"list_name"`
+	Member   *Member            `json:"member"`
+	Activity MemberActivityFeed `json:"activity"`
+	Goals    []MemberGoal       `json:"goals"`
+	Notes    []MemberNoteLong   `json:"notes"`
+	Events   []MemberEvent      `json:"events"`
+	Tags     []MemberTagLong    `json:"tags"`
+}
+
+// StoreCustomerData is an ecommerce customer with the email address
+type StoreCustomerData struct {
+	StoreID  string   `json:"store_id"`
+	Customer Customer `json:"customer"`
+	Orders   []Order  `json:"orders"`
+}
+
+// WriteJSON writes the bundle as indented JSON
+func (bundle *MemberDataBundle) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bundle)
+}
+
+// WriteCSV writes the bundle as CSV, one row per value with the columns
+// section, source, record, field and value. Nested values are flattened
+// into dotted field names.
+func (bundle *MemberDataBundle) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"section", "source", "record", "field", "value"}}
+
+	add := func(section, source, record string, v interface{}) error {
+		fields, err := flattenRecord(v)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			rows = append(rows, []string{section, source, record, field[0], field[1]})
+		}
+		return nil
+	}
+
+	for _, data := range bundle.Lists {
+		if err := add("member", data.ListID, data.Member.ID, data.Member); err != nil {
+			return err
+		}
+		for i := range data.Activity {
+			if err := add("activity", data.ListID, strconv.Itoa(i), &data.Activity[i]); err != nil {
+				return err
+			}
+		}
+		for i := range data.Goals {
+			if err := add("goal", data.ListID, strconv.Itoa(data.Goals[i].ID), &data.Goals[i]); err != nil {
+				return err
+			}
+		}
+		for i := range data.Notes {
+			if err := add("note", data.ListID, strconv.Itoa(data.Notes[i].ID), &data.Notes[i]); err != nil {
+				return err
+			}
+		}
+		for i := range data.Events {
+			if err := add("event", data.ListID, strconv.Itoa(i), &data.Events[i]); err != nil {
+				return err
+			}
+		}
+		for i := range data.Tags {
+			if err := add("tag", data.ListID, strconv.Itoa(data.Tags[i].ID), &data.Tags[i]); err != nil {
+				return err
+			}
+		}
+		for i := range data.Member.MarketingPermissions {
+			permission := &data.Member.MarketingPermissions[i]
+			if err := add("marketing_permission", data.ListID, permission.MarketingPermissionID, permission); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, data := range bundle.Customers {
+		if err := add("customer", data.StoreID, data.Customer.ID, &data.Customer); err != nil {
+			return err
+		}
+		for i := range data.Orders {
+			if err := add("order", data.StoreID, data.Orders[i].ID, &data.Orders[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// flattenRecord returns the sorted field/value pairs of the JSON form of v,
+// skipping links.
+func flattenRecord(v interface{}) ([][2]string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	flat := make(map[string]string)
+	flattenValue("", decoded, flat)
+
+	fields := make([][2]string, 0, len(flat))
+	for field, value := range flat {
+		fields = append(fields, [2]string{field, value})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i][0] < fields[j][0] })
+	return fields, nil
+}
+
+func flattenValue(prefix string, v interface{}, out map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if key == "_links" || key == "_link" {
+				continue
+			}
+			flattenValue(join(key), value, out)
+		}
+	case []interface{}:
+		for i, value := range v {
+			flattenValue(join(strconv.Itoa(i)), value, out)
+		}
+	case nil:
+		out[prefix] = ""
+	case string:
+		out[prefix] = v
+	default:
+		data, _ := json.Marshal(v)
+		out[prefix] = string(data)
+	}
+}
+
+// ExportMemberData gathers everything Mailchimp stores about email for a data
+// subject access request: the member of every list it is on with its
+// activity, goals, notes, events, tags and marketing permissions, and the
+// matching ecommerce customers of every store with their orders. The requests
+// are aborted when ctx is cancelled.
+func (api *API) ExportMemberData(ctx context.Context, email string) (*MemberDataBundle, error) {
+	return api.WithContext(ctx).collectMemberData(email)
+}
+
+// collectMemberData gathers the data of email from every list it is on and
+// every store with a customer having it.
+func (api *API) collectMemberData(email string) (*MemberDataBundle, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	bundle := &MemberDataBundle{
+		EmailAddress:   email,
+		SubscriberHash: SubscriberHash(email),
+		ExportedAt:     time.Now().UTC(),
+		Lists:          []ListMemberData{},
+		Customers:      []StoreCustomerData{},
+	}
+
+	lists, err := api.getListsWithEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range lists {
+		data, err := lists[i].collectMemberData(email)
+		if err != nil {
+			return nil, fmt.Errorf("collecting data of list %s: %v", lists[i].ID, err)
+		}
+		if data != nil {
+			bundle.Lists = append(bundle.Lists, *data)
+		}
+	}
+
+	stores, err := api.getAllStores()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stores {
+		customers, err := stores[i].getCustomersByEmail(email)
+		if err != nil {
+			return nil, fmt.Errorf("collecting customers of store %s: %v", stores[i].ID, err)
+		}
+		for _, customer := range customers {
+			orders, err := stores[i].getCustomerOrders(customer.ID)
+			if err != nil {
+				return nil, fmt.Errorf("collecting orders of store %s: %v", stores[i].ID, err)
+			}
+			bundle.Customers = append(bundle.Customers, StoreCustomerData{StoreID: stores[i].ID, Customer: customer, Orders: orders})
+		}
+	}
+
+	return bundle, nil
+}
+
+func (list *ListResponse) collectMemberData(email string) (*ListMemberData, error) {
+	member, err := list.GetMemberByEmail(email, nil)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ListMemberData{ListID: list.ID, ListName: list.Name, Member: member}
+
+	if data.Activity, err = member.GetAllActivityFeed(); err != nil {
+		return nil, err
+	}
+
+	goals, err := member.GetGoals(nil)
+	if err != nil {
+		return nil, err
+	}
+	data.Goals = goals.Goals
+
+	if data.Notes, err = member.getAllNotes(); err != nil {
+		return nil, err
+	}
+	if data.Events, err = member.getAllEvents(); err != nil {
+		return nil, err
+	}
+	if data.Tags, err = member.getAllTags(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (api *API) getListsWithEmail(email string) ([]ListResponse, error) {
+	return api.getAllLists(&ListQueryParams{Email: email})
+}
+
+// getAllLists pages through the lists matching params
+func (api *API) getAllLists(params *ListQueryParams) ([]ListResponse, error) {
+	var lists []ListResponse
+	err := pageThrough(&params.ExtendedQueryParams, func() (int, int, error) {
+		page, err := api.GetLists(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		lists = append(lists, page.Lists...)
+		return len(page.Lists), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (api *API) getAllStores() ([]Store, error) {
+	params := &ExtendedQueryParams{}
+
+	var stores []Store
+	err := pageThrough(params, func() (int, int, error) {
+		page, err := api.GetStores(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		stores = append(stores, page.Stores...)
+		return len(page.Stores), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+func (store *Store) getCustomersByEmail(email string) ([]Customer, error) {
+	params := &CustomerQueryParams{EmailAddress: email}
+
+	var customers []Customer
+	err := pageThrough(&params.ExtendedQueryParams, func() (int, int, error) {
+		page, err := store.GetCustomers(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		customers = append(customers, page.Customers...)
+		return len(page.Customers), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return customers, nil
+}
+
+func (store *Store) getCustomerOrders(customerID string) ([]Order, error) {
+	params := &OrderQueryParams{CustomerID: customerID}
+
+	orders := []Order{}
+	err := pageThrough(&params.ExtendedQueryParams, func() (int, int, error) {
+		page, err := store.GetOrders(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		orders = append(orders, page.Orders...)
+		return len(page.Orders), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (mem *Member) getAllNotes() ([]MemberNoteLong, error) {
+	params := &ExtendedQueryParams{}
+
+	notes := []MemberNoteLong{}
+	err := pageThrough(params, func() (int, int, error) {
+		page, err := mem.GetNotes(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		notes = append(notes, page.Notes...)
+		return len(page.Notes), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (mem *Member) getAllEvents() ([]MemberEvent, error) {
+	params := &ExtendedQueryParams{}
+
+	events := []MemberEvent{}
+	err := pageThrough(params, func() (int, int, error) {
+		page, err := mem.GetEvents(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		events = append(events, page.Events...)
+		return len(page.Events), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (mem *Member) getAllTags() ([]MemberTagLong, error) {
+	params := &ExtendedQueryParams{}
+
+	tags := []MemberTagLong{}
+	err := pageThrough(params, func() (int, int, error) {
+		page, err := mem.GetTags(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		tags = append(tags, page.Tags...)
+		return len(page.Tags), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// Erasure
+// ------------------------------------------------------------------------------------------------
+
+const (
+	ERASURE_TARGET_LIST_MEMBER    = "list_member"
+	ERASURE_TARGET_STORE_CUSTOMER = "store_customer"
+)
+
+// ErasureStep is the deletion of the email address from one list or store.
+// Verified is set once Mailchimp no longer returns the deleted record.
+type ErasureStep struct {
+	Target   string `json:"target"` // one of ERASURE_TARGET_*
+	ParentID string `json:"parent_id"`
+	ID       string `json:"id"`
+	Deleted  bool   `json:"deleted"`
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ErasureReport records the erasure of an email address. BundleSHA256 is the
+// digest of the JSON written by Bundle.WriteJSON, so the exported data handed
+// over can be matched with the report.
+type ErasureReport struct {
+	EmailAddress   string        `json:"email_address"`
+	SubscriberHash string        `json:"subscriber_hash"`
+	StartedAt      time.Time     `json:"started_at"`
+	FinishedAt     time.Time     `json:"finished_at"`
+	BundleSHA256   string        `json:"bundle_sha256"`
+	Steps          []ErasureStep `json:"steps"`
+	Verified       bool          `json:"verified"`
+
+	Bundle *MemberDataBundle `json:"-"`
+}
+
+// WriteJSON writes the report as indented JSON
+func (report *ErasureReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// EraseMember exports everything about email, then permanently deletes it
+// from every list and its customer records from every store. Deletion
+// failures are recorded in the report steps rather than stopping the erasure,
+// report.Verified tells whether everything is gone. Permanently deleted
+// members can not be re-imported to the list. The requests are aborted when
+// ctx is cancelled.
+func (api *API) EraseMember(ctx context.Context, email string) (*ErasureReport, error) {
+	api = api.WithContext(ctx)
+	report := &ErasureReport{
+		EmailAddress:   strings.ToLower(strings.TrimSpace(email)),
+		SubscriberHash: SubscriberHash(email),
+		StartedAt:      time.Now().UTC(),
+		Steps:          []ErasureStep{},
+	}
+
+	bundle, err := api.collectMemberData(email)
+	if err != nil {
+		return nil, err
+	}
+	report.Bundle = bundle
+
+	digest := sha256.New()
+	if err := bundle.WriteJSON(digest); err != nil {
+		return nil, err
+	}
+	report.BundleSHA256 = fmt.Sprintf("%x", digest.Sum(nil))
+
+	for _, data := range bundle.Lists {
+		step := ErasureStep{Target: ERASURE_TARGET_LIST_MEMBER, ParentID: data.ListID, ID: report.SubscriberHash}
+		list := api.NewListResponse(data.ListID)
+
+		if _, err := list.DeleteMemberPermanent(report.SubscriberHash); err != nil {
+			step.Error = err.Error()
+		} else {
+			step.Deleted = true
+			_, err := list.GetMember(report.SubscriberHash, nil)
+			step.Verified = isNotFound(err)
+		}
+		report.Steps = append(report.Steps, step)
+	}
+
+	for _, data := range bundle.Customers {
+		step := ErasureStep{Target: ERASURE_TARGET_STORE_CUSTOMER, ParentID: data.StoreID, ID: data.Customer.ID}
+		store := &Store{ID: data.StoreID, api: api}
+
+		if _, err := store.DeleteCustomer(data.Customer.ID); err != nil {
+			step.Error = err.Error()
+		} else {
+			step.Deleted = true
+			_, err := store.GetCustomer(data.Customer.ID, nil)
+			step.Verified = isNotFound(err)
+		}
+		report.Steps = append(report.Steps, step)
+	}
+
+	report.Verified = true
+	for _, step := range report.Steps {
+		report.Verified = report.Verified && step.Verified
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	return report, nil
+}
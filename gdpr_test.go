@@ -0,0 +1,169 @@
+package gochimp3
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeMemberDataServer(t *testing.T) *http.ServeMux {
+	hash := SubscriberHash("ada@example.com")
+	notFound := func(w http.ResponseWriter) {
+		w.WriteHeader(404)
+		writeJSON(w, &APIError{Status: 404, Type: "not found", Title: "Resource Not Found"})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ada@example.com", r.URL.Query().Get("email"))
+		lists := &ListOfLists{Lists: []ListResponse{{ID: "list1"}, {ID: "list2"}}}
+		lists.TotalItems = 2
+		writeJSON(w, lists)
+	})
+	mux.HandleFunc("/lists/list2/members/"+hash, func(w http.ResponseWriter, r *http.Request) {
+		notFound(w)
+	})
+	mux.HandleFunc("/lists/list1/members/"+hash, func(w http.ResponseWriter, r *http.Request) {
+		member := &Member{ID: hash, ListID: "list1"}
+		member.EmailAddress = "ada@example.com"
+		member.Status = MEMBER_STATUS_SUBSCRIBED
+		member.MarketingPermissions = MarketingPermissions{{MarketingPermissionID: "p1", Text: "Email", Enabled: true}}
+		writeJSON(w, member)
+	})
+	mux.HandleFunc("/lists/list1/members/"+hash+"/activity-feed", func(w http.ResponseWriter, r *http.Request) {
+		// one more than a page, so the feed is requested twice
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		page := &ListOfMemberActivityFeed{}
+		page.TotalItems = maxPageSize + 1
+		for i := offset; i < page.TotalItems && i < offset+maxPageSize; i++ {
+			page.Activity = append(page.Activity, MemberActivityFeedItem{ActivityType: MEMBER_ACTIVITY_OPEN, CampaignID: "c1"})
+		}
+		writeJSON(w, page)
+	})
+	mux.HandleFunc("/lists/list1/members/"+hash+"/goals", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfMemberGoals{})
+	})
+	mux.HandleFunc("/lists/list1/members/"+hash+"/notes", func(w http.ResponseWriter, r *http.Request) {
+		notes := &ListOfMemberNotes{Notes: []MemberNoteLong{{ID: 3, Note: "called support"}}}
+		notes.TotalItems = 1
+		writeJSON(w, notes)
+	})
+	mux.HandleFunc("/lists/list1/members/"+hash+"/events", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfMemberEvents{})
+	})
+	mux.HandleFunc("/lists/list1/members/"+hash+"/tags", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfMemberTags{Tags: []MemberTagLong{{ID: 9, Name: "vip"}}})
+	})
+	mux.HandleFunc("/ecommerce/stores", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &StoreList{Stores: []Store{{ID: "store1"}}, TotalItems: 1})
+	})
+	mux.HandleFunc("/ecommerce/stores/store1/customers", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ada@example.com", r.URL.Query().Get("email_address"))
+		writeJSON(w, &CustomerList{Customers: []Customer{{ID: "cust1", EmailAddress: "ada@example.com", TotalSpent: 42}}, TotalItems: 1})
+	})
+	mux.HandleFunc("/ecommerce/stores/store1/orders", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "cust1", r.URL.Query().Get("customer_id"))
+		writeJSON(w, map[string]interface{}{"orders": []map[string]interface{}{{"id": "order1", "order_total": 42}}, "total_items": 1})
+	})
+	return mux
+}
+
+func TestExportMemberData(t *testing.T) {
+	api, server := testAPIServer(fakeMemberDataServer(t))
+	defer server.Close()
+
+	bundle, err := api.ExportMemberData(context.Background(), " Ada@Example.com ")
+	fatalIf(t, err)
+
+	assert.Equal(t, "ada@example.com", bundle.EmailAddress)
+	if assert.Len(t, bundle.Lists, 1) {
+		data := bundle.Lists[0]
+		assert.Equal(t, "list1", data.ListID)
+		assert.Equal(t, "p1", data.Member.MarketingPermissions[0].MarketingPermissionID)
+		assert.Len(t, data.Activity, maxPageSize+1)
+		assert.Len(t, data.Notes, 1)
+		assert.Len(t, data.Tags, 1)
+	}
+	if assert.Len(t, bundle.Customers, 1) {
+		assert.Equal(t, "cust1", bundle.Customers[0].Customer.ID)
+		assert.Equal(t, "order1", bundle.Customers[0].Orders[0].ID)
+	}
+
+	buf := new(bytes.Buffer)
+	fatalIf(t, bundle.WriteCSV(buf))
+	rows, err := csv.NewReader(buf).ReadAll()
+	fatalIf(t, err)
+	assert.Equal(t, []string{"section", "source", "record", "field", "value"}, rows[0])
+	assert.Contains(t, rows, []string{"note", "list1", "3", "note", "called support"})
+	assert.Contains(t, rows, []string{"marketing_permission", "list1", "p1", "enabled", "true"})
+	assert.Contains(t, rows, []string{"customer", "store1", "cust1", "total_spent", "42"})
+	assert.Contains(t, rows, []string{"order", "store1", "order1", "order_total", "42"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = api.ExportMemberData(ctx, "ada@example.com")
+	assert.Error(t, err)
+}
+
+func TestEraseMember(t *testing.T) {
+	hash := SubscriberHash("ada@example.com")
+	var deleted []string
+	failDelete := false
+
+	mux := fakeMemberDataServer(t)
+	mux.HandleFunc("/lists/list1/members/"+hash+"/actions/delete-permanent", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		if failDelete {
+			http.Error(w, `{"status": 500, "title": "Internal Server Error"}`, 500)
+			return
+		}
+		deleted = append(deleted, "list1")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/ecommerce/stores/store1/customers/cust1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deleted = append(deleted, "cust1")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(404)
+		writeJSON(w, &APIError{Status: 404, Type: "not found", Title: "Resource Not Found"})
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	// the fake keeps returning the member of list1 once deleted
+	report, err := api.EraseMember(context.Background(), "Ada@Example.com")
+	fatalIf(t, err)
+	assert.Equal(t, []string{"list1", "cust1"}, deleted)
+	assert.Equal(t, "ada@example.com", report.EmailAddress)
+	assert.NotEmpty(t, report.BundleSHA256)
+	assert.Equal(t, []ErasureStep{
+		{Target: ERASURE_TARGET_LIST_MEMBER, ParentID: "list1", ID: hash, Deleted: true},
+		{Target: ERASURE_TARGET_STORE_CUSTOMER, ParentID: "store1", ID: "cust1", Deleted: true, Verified: true},
+	}, report.Steps)
+	assert.False(t, report.Verified)
+
+	deleted = nil
+	failDelete = true
+	report, err = api.EraseMember(context.Background(), "ada@example.com")
+	fatalIf(t, err)
+	assert.Equal(t, []string{"cust1"}, deleted)
+	if assert.Len(t, report.Steps, 2) {
+		assert.False(t, report.Steps[0].Deleted)
+		assert.NotEmpty(t, report.Steps[0].Error)
+		assert.True(t, report.Steps[1].Verified)
+	}
+	assert.False(t, report.Verified)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = api.EraseMember(ctx, "ada@example.com")
+	assert.Error(t, err)
+}
@@ -2,4 +2,7 @@ module github.com/hanzoai/gochimp3
 
 go 1.13
 
-require github.com/stretchr/testify v1.5.1
+require (
+	github.com/stretchr/testify v1.5.1
+	gopkg.in/yaml.v2 v2.4.0
+)
@@ -0,0 +1,405 @@
+package gochimp3
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// interestPathSeparator separates the category from the interest in names
+// such as "Newsletter > Weekly"
+const interestPathSeparator = ">"
+
+// InterestCatalog holds the interest categories of a list with their
+// interests, to find interests by name.
+type InterestCatalog struct {
+	Categories []InterestCategory
+
+	// Interests are keyed by category ID
+	Interests map[string][]Interest
+}
+
+// GetInterestCatalog pages through the interest categories of the list and
+// their interests.
+func (list *ListResponse) GetInterestCatalog() (*InterestCatalog, error) {
+	params := &InterestCategoriesQueryParams{}
+
+	catalog := &InterestCatalog{Interests: make(map[string][]Interest)}
+	err := pageThrough(&params.ExtendedQueryParams, func() (int, int, error) {
+		page, err := list.GetInterestCategories(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		catalog.Categories = append(catalog.Categories, page.Categories...)
+		return len(page.Categories), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range catalog.Categories {
+		category := &catalog.Categories[i]
+		if category.ListID == "" {
+			category.ListID = list.ID
+		}
+
+		interests, err := list.getAllInterests(category.ID)
+		if err != nil {
+			return nil, err
+		}
+		catalog.Interests[category.ID] = interests
+	}
+
+	return catalog, nil
+}
+
+func (list *ListResponse) getAllInterests(interestCategoryID string) ([]Interest, error) {
+	params := &ExtendedQueryParams{}
+
+	var interests []Interest
+	err := pageThrough(params, func() (int, int, error) {
+		page, err := list.GetInterests(interestCategoryID, params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		interests = append(interests, page.Interests...)
+		return len(page.Interests), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return interests, nil
+}
+
+// InterestName returns the name of an interest as used by the catalog
+func InterestName(category, interest string) string {
+	return category + " " + interestPathSeparator + " " + interest
+}
+
+// splitInterestName splits "Category > Interest", ignoring the spaces around
+// the separator
+func splitInterestName(name string) (string, string, error) {
+	i := strings.LastIndex(name, interestPathSeparator)
+	if i < 0 {
+		return "", "", fmt.Errorf("Interest name %q is not in the form \"Category > Interest\"", name)
+	}
+	return strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+len(interestPathSeparator):]), nil
+}
+
+// Category returns the category with the given title, ignoring case
+func (catalog *InterestCatalog) Category(title string) (*InterestCategory, bool) {
+	for i := range catalog.Categories {
+		if strings.EqualFold(catalog.Categories[i].Title, strings.TrimSpace(title)) {
+			return &catalog.Categories[i], true
+		}
+	}
+	return nil, false
+}
+
+// Find returns the interest named "Category > Interest", ignoring case
+func (catalog *InterestCatalog) Find(name string) (*Interest, error) {
+	categoryTitle, interestName, err := splitInterestName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	category, ok := catalog.Category(categoryTitle)
+	if !ok {
+		return nil, fmt.Errorf("No interest category %q", categoryTitle)
+	}
+
+	interests := catalog.Interests[category.ID]
+	for i := range interests {
+		if strings.EqualFold(interests[i].Name, interestName) {
+			return &interests[i], nil
+		}
+	}
+	return nil, fmt.Errorf("No interest %q in the category %q", interestName, category.Title)
+}
+
+// Name returns the "Category > Interest" name of the interest with the given
+// ID, or an empty string.
+func (catalog *InterestCatalog) Name(id string) string {
+	for _, category := range catalog.Categories {
+		for _, interest := range catalog.Interests[category.ID] {
+			if interest.ID == id {
+				return InterestName(category.Title, interest.Name)
+			}
+		}
+	}
+	return ""
+}
+
+// MemberInterests converts interests keyed by name to the interests keyed by
+// ID of MemberRequest.Interests.
+func (catalog *InterestCatalog) MemberInterests(byName map[string]bool) (map[string]bool, error) {
+	byID := make(map[string]bool, len(byName))
+	for name, enabled := range byName {
+		interest, err := catalog.Find(name)
+		if err != nil {
+			return nil, err
+		}
+		byID[interest.ID] = enabled
+	}
+	return byID, nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// Sync
+// ------------------------------------------------------------------------------------------------
+
+// InterestSpec is the desired interest categories of a list, in display
+// order, e.g. read from YAML with ParseInterestSpec:
+//
+//	categories:
+//	  - title: Newsletter
+//	    type: checkboxes
+//	    interests: [Weekly, Monthly]
+type InterestSpec struct {
+	Categories []InterestCategorySpec `yaml:"categories" json:"categories"`
+}
+
+// InterestCategorySpec is a category with its interests in display order,
+// Type is one of INTEREST_CATEGORY_TYPE_* and defaults to checkboxes.
+type InterestCategorySpec struct {
+	Title     string   `yaml:"title" json:"title"`
+	Type      string   `yaml:"type" json:"type"`
+	Interests []string `yaml:"interests" json:"interests"`
+}
+
+// ParseInterestSpec reads an InterestSpec from YAML, rejecting unknown keys
+func ParseInterestSpec(data []byte) (*InterestSpec, error) {
+	spec := new(InterestSpec)
+	if err := yaml.UnmarshalStrict(data, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// InterestCategoryCreate is a category to add with its interests, Desired
+// holds its position in the spec.
+type InterestCategoryCreate struct {
+	Spec    InterestCategorySpec
+	Desired InterestCategoryRequest
+}
+
+// InterestCategoryUpdate is a category whose type or order differs
+type InterestCategoryUpdate struct {
+	Current InterestCategory
+	Desired InterestCategoryRequest
+}
+
+// InterestCreate is an interest to add to an existing category
+type InterestCreate struct {
+	Category InterestCategory
+	Desired  InterestRequest
+}
+
+// InterestUpdate is an interest whose order differs
+type InterestUpdate struct {
+	Category InterestCategory
+	Current  Interest
+	Desired  InterestRequest
+}
+
+// InterestPlan lists the changes needed to make the interest categories of a
+// list match an InterestSpec. Categories and interests are matched by name,
+// ignoring case, so renaming one deletes it and creates a new one.
+type InterestPlan struct {
+	CreateCategories []InterestCategoryCreate
+	UpdateCategories []InterestCategoryUpdate
+	DeleteCategories []InterestCategory
+
+	CreateInterests []InterestCreate
+	UpdateInterests []InterestUpdate
+	DeleteInterests []InterestUpdate
+}
+
+// IsEmpty returns true when the list already matches the spec
+func (plan *InterestPlan) IsEmpty() bool {
+	return len(plan.CreateCategories) == 0 && len(plan.UpdateCategories) == 0 && len(plan.DeleteCategories) == 0 &&
+		len(plan.CreateInterests) == 0 && len(plan.UpdateInterests) == 0 && len(plan.DeleteInterests) == 0
+}
+
+// PlanInterests compares the interest categories of the list with spec
+// without changing anything.
+func (list *ListResponse) PlanInterests(spec *InterestSpec) (*InterestPlan, error) {
+	catalog, err := list.GetInterestCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	return planInterests(catalog, spec)
+}
+
+// SyncInterests creates, updates and deletes interest categories and
+// interests so the list ends up with exactly the ones of spec. Deleting an
+// interest removes it from every member.
+//
+// The returned plan is the one that was applied, on error it may have been
+// applied only partially.
+func (list *ListResponse) SyncInterests(spec *InterestSpec) (*InterestPlan, error) {
+	plan, err := list.PlanInterests(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, list.ApplyInterestPlan(plan)
+}
+
+// ApplyInterestPlan applies the deletes, updates and creates of plan in that
+// order, stopping at the first error.
+func (list *ListResponse) ApplyInterestPlan(plan *InterestPlan) error {
+	for _, del := range plan.DeleteInterests {
+		category := del.Category
+		category.api = list.api
+		if _, err := category.DeleteInterest(del.Current.ID); err != nil {
+			return fmt.Errorf("deleting interest %s: %v", InterestName(del.Category.Title, del.Current.Name), err)
+		}
+	}
+
+	for _, category := range plan.DeleteCategories {
+		if _, err := list.DeleteInterestCategory(category.ID); err != nil {
+			return fmt.Errorf("deleting interest category %s: %v", category.Title, err)
+		}
+	}
+
+	for i := range plan.UpdateCategories {
+		update := &plan.UpdateCategories[i]
+		if _, err := list.UpdateInterestCategory(update.Current.ID, &update.Desired); err != nil {
+			return fmt.Errorf("updating interest category %s: %v", update.Current.Title, err)
+		}
+	}
+
+	for i := range plan.UpdateInterests {
+		update := &plan.UpdateInterests[i]
+		category := update.Category
+		category.api = list.api
+		if _, err := category.UpdateInterest(update.Current.ID, &update.Desired); err != nil {
+			return fmt.Errorf("updating interest %s: %v", InterestName(update.Category.Title, update.Current.Name), err)
+		}
+	}
+
+	for i := range plan.CreateCategories {
+		create := &plan.CreateCategories[i]
+		spec := create.Spec
+		category, err := list.CreateInterestCategory(&create.Desired)
+		if err != nil {
+			return fmt.Errorf("creating interest category %s: %v", spec.Title, err)
+		}
+		if category.ListID == "" {
+			category.ListID = list.ID
+		}
+
+		for order, name := range spec.Interests {
+			if _, err := category.CreateInterest(&InterestRequest{Name: name, DisplayOrder: order}); err != nil {
+				return fmt.Errorf("creating interest %s: %v", InterestName(spec.Title, name), err)
+			}
+		}
+	}
+
+	for i := range plan.CreateInterests {
+		create := &plan.CreateInterests[i]
+		category := create.Category
+		category.api = list.api
+		if _, err := category.CreateInterest(&create.Desired); err != nil {
+			return fmt.Errorf("creating interest %s: %v", InterestName(category.Title, create.Desired.Name), err)
+		}
+	}
+
+	return nil
+}
+
+func planInterests(catalog *InterestCatalog, spec *InterestSpec) (*InterestPlan, error) {
+	plan := new(InterestPlan)
+	wanted := make(map[string]bool, len(spec.Categories))
+
+	for order, desired := range spec.Categories {
+		title := strings.TrimSpace(desired.Title)
+		if title == "" {
+			return nil, fmt.Errorf("interest category %d has no title", order)
+		}
+		if wanted[strings.ToLower(title)] {
+			return nil, fmt.Errorf("interest category %s is declared twice", title)
+		}
+		wanted[strings.ToLower(title)] = true
+
+		if desired.Type == "" {
+			desired.Type = INTEREST_CATEGORY_TYPE_CHECKBOXES
+		}
+		desired.Title = title
+
+		category, ok := catalog.Category(title)
+		if !ok {
+			plan.CreateCategories = append(plan.CreateCategories, InterestCategoryCreate{
+				Spec:    desired,
+				Desired: InterestCategoryRequest{Title: title, Type: desired.Type, DisplayOrder: order},
+			})
+			continue
+		}
+
+		if category.Type != desired.Type || category.DisplayOrder != order {
+			plan.UpdateCategories = append(plan.UpdateCategories, InterestCategoryUpdate{
+				Current: *category,
+				Desired: InterestCategoryRequest{Title: category.Title, Type: desired.Type, DisplayOrder: order},
+			})
+		}
+
+		if err := planCategoryInterests(plan, *category, catalog.Interests[category.ID], desired.Interests); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, category := range catalog.Categories {
+		if !wanted[strings.ToLower(strings.TrimSpace(category.Title))] {
+			plan.DeleteCategories = append(plan.DeleteCategories, category)
+		}
+	}
+
+	return plan, nil
+}
+
+func planCategoryInterests(plan *InterestPlan, category InterestCategory, current []Interest, desired []string) error {
+	byName := make(map[string]Interest, len(current))
+	for _, interest := range current {
+		byName[strings.ToLower(strings.TrimSpace(interest.Name))] = interest
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for order, name := range desired {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if key == "" {
+			return fmt.Errorf("interest %d of the category %s has no name", order, category.Title)
+		}
+		if wanted[key] {
+			return fmt.Errorf("interest %s is declared twice", InterestName(category.Title, name))
+		}
+		wanted[key] = true
+
+		interest, ok := byName[key]
+		switch {
+		case !ok:
+			plan.CreateInterests = append(plan.CreateInterests, InterestCreate{
+				Category: category,
+				Desired:  InterestRequest{Name: name, DisplayOrder: order},
+			})
+		case interest.DisplayOrder != order:
+			plan.UpdateInterests = append(plan.UpdateInterests, InterestUpdate{
+				Category: category,
+				Current:  interest,
+				Desired:  InterestRequest{Name: interest.Name, DisplayOrder: order},
+			})
+		}
+	}
+
+	for _, interest := range current {
+		if !wanted[strings.ToLower(strings.TrimSpace(interest.Name))] {
+			plan.DeleteInterests = append(plan.DeleteInterests, InterestUpdate{Category: category, Current: interest})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncInterests(t *testing.T) {
+	var requests []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/interest-categories", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			body := InterestCategoryRequest{}
+			fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+			requests = append(requests, "POST category "+body.Title+" "+body.Type)
+			category := &InterestCategory{InterestCategoryRequest: body, ID: "cat3"}
+			writeJSON(w, category)
+			return
+		}
+		categories := &ListOfInterestCategories{Categories: []InterestCategory{{ID: "cat1", ListID: "list1"}, {ID: "cat2", ListID: "list1"}}}
+		categories.Categories[0].Title = "Newsletter"
+		categories.Categories[0].Type = INTEREST_CATEGORY_TYPE_CHECKBOXES
+		categories.Categories[1].Title = "Legacy"
+		categories.TotalItems = 2
+		writeJSON(w, categories)
+	})
+	mux.HandleFunc("/lists/list1/interest-categories/cat1/interests", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			body := InterestRequest{}
+			fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+			requests = append(requests, "POST cat1 "+body.Name)
+			writeJSON(w, &Interest{ID: "i3", Name: body.Name})
+			return
+		}
+		interests := &ListOfInterests{Interests: []Interest{{ID: "i1", Name: "Weekly", DisplayOrder: 1}, {ID: "i2", Name: "Daily"}}}
+		interests.TotalItems = 2
+		writeJSON(w, interests)
+	})
+	mux.HandleFunc("/lists/list1/interest-categories/cat2/interests", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfInterests{})
+	})
+	mux.HandleFunc("/lists/list1/interest-categories/cat3/interests", func(w http.ResponseWriter, r *http.Request) {
+		body := InterestRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, "POST cat3 "+body.Name)
+		writeJSON(w, &Interest{Name: body.Name})
+	})
+	for _, path := range []string{"/lists/list1/interest-categories/cat2", "/lists/list1/interest-categories/cat1/interests/i1", "/lists/list1/interest-categories/cat1/interests/i2"} {
+		path := path
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+path)
+			if r.Method == "DELETE" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			writeJSON(w, &Interest{})
+		})
+	}
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+	list := api.NewListResponse("list1")
+
+	catalog, err := list.GetInterestCatalog()
+	fatalIf(t, err)
+	interest, err := catalog.Find("newsletter >weekly")
+	fatalIf(t, err)
+	assert.Equal(t, "i1", interest.ID)
+	assert.Equal(t, "Newsletter > Daily", catalog.Name("i2"))
+	_, err = catalog.Find("Newsletter > Monthly")
+	assert.Error(t, err)
+	_, err = catalog.Find("Weekly")
+	assert.Error(t, err)
+
+	interests, err := catalog.MemberInterests(map[string]bool{"Newsletter > Weekly": true, "Newsletter > Daily": false})
+	fatalIf(t, err)
+	assert.Equal(t, map[string]bool{"i1": true, "i2": false}, interests)
+
+	spec, err := ParseInterestSpec([]byte(`
+categories:
+  - title: Newsletter
+    interests: [Weekly, Monthly]
+  - title: Products
+    type: radio
+    interests: [Shoes]
+`))
+	fatalIf(t, err)
+	_, err = ParseInterestSpec([]byte("categories:\n  - name: Newsletter\n"))
+	assert.Error(t, err)
+
+	plan, err := list.PlanInterests(spec)
+	fatalIf(t, err)
+	assert.False(t, plan.IsEmpty())
+	assert.Empty(t, requests)
+
+	_, err = list.SyncInterests(spec)
+	fatalIf(t, err)
+	assert.Equal(t, []string{
+		"DELETE /lists/list1/interest-categories/cat1/interests/i2",
+		"DELETE /lists/list1/interest-categories/cat2",
+		"PATCH /lists/list1/interest-categories/cat1/interests/i1",
+		"POST category Products radio",
+		"POST cat3 Shoes",
+		"POST cat1 Monthly",
+	}, requests)
+
+	_, err = planInterests(catalog, &InterestSpec{Categories: []InterestCategorySpec{{Title: "A"}, {Title: "a"}}})
+	assert.Error(t, err)
+}
+
+func TestSyncInterestsIsIdempotent(t *testing.T) {
+	categories := []InterestCategory{{ID: "cat1", ListID: "list1"}}
+	categories[0].Title = "Newsletter"
+	categories[0].Type = INTEREST_CATEGORY_TYPE_CHECKBOXES
+	interests := map[string][]Interest{"cat1": {{ID: "i1", Name: "Weekly"}}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/interest-categories", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			category := InterestCategory{ID: fmt.Sprintf("cat%d", len(categories)+1), ListID: "list1"}
+			fatalIf(t, json.NewDecoder(r.Body).Decode(&category.InterestCategoryRequest))
+			categories = append(categories, category)
+			writeJSON(w, &category)
+			return
+		}
+		page := &ListOfInterestCategories{Categories: categories}
+		page.TotalItems = len(categories)
+		writeJSON(w, page)
+	})
+	mux.HandleFunc("/lists/list1/interest-categories/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/lists/list1/interest-categories/"), "/")
+		if len(parts) != 2 || r.Method != "GET" && r.Method != "POST" {
+			t.Fatalf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		categoryID := parts[0]
+		if r.Method == "POST" {
+			interest := Interest{ID: fmt.Sprintf("%s-%d", categoryID, len(interests[categoryID])), CategoryID: categoryID}
+			fatalIf(t, json.NewDecoder(r.Body).Decode(&interest))
+			interests[categoryID] = append(interests[categoryID], interest)
+			writeJSON(w, &interest)
+			return
+		}
+		writeJSON(w, &ListOfInterests{Interests: interests[categoryID], TotalItems: len(interests[categoryID])})
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+	list := api.NewListResponse("list1")
+
+	spec := &InterestSpec{Categories: []InterestCategorySpec{
+		{Title: "Newsletter", Interests: []string{"Weekly"}},
+		{Title: "Products", Interests: []string{"Shoes", "Hats"}},
+	}}
+
+	plan, err := list.SyncInterests(spec)
+	fatalIf(t, err)
+	if assert.Len(t, plan.CreateCategories, 1) {
+		assert.Equal(t, 1, plan.CreateCategories[0].Desired.DisplayOrder)
+	}
+	assert.Equal(t, 1, categories[1].DisplayOrder)
+
+	plan, err = list.PlanInterests(spec)
+	fatalIf(t, err)
+	assert.True(t, plan.IsEmpty(), "%+v", plan)
+}
@@ -3,12 +3,21 @@ package gochimp3
 import (
 	"encoding/json"
 	"strings"
+	"time"
 )
 
 const (
 	timeFormat = "2006-01-02T15:04:05-07:00"
 )
 
+// formatQueryTime formats t for a query parameter, the zero time is omitted
+func formatQueryTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timeFormat)
+}
+
 func (address *Address) MarshalJSON() ([]byte, error) {
 	tmp := struct {
 		Address
@@ -0,0 +1,452 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// ListHealthReporter assembles the growth, engagement and abuse statistics of
+// a list into a ListHealthReport.
+type ListHealthReporter struct {
+	List *ListResponse
+
+	// Months is the number of months of growth and abuse reports kept
+	Months int
+
+	// TopClients is the number of email clients kept
+	TopClients int
+
+	// Previous is the last report of the list, its rating history is carried
+	// over since the API only returns the current rating.
+	Previous *ListHealthReport
+
+	// Now returns the current time, it can be replaced in tests
+	Now func() time.Time
+}
+
+func (list *ListResponse) NewListHealthReporter() *ListHealthReporter {
+	return &ListHealthReporter{
+		List:       list,
+		Months:     12,
+		TopClients: 5,
+		Now:        time.Now,
+	}
+}
+
+// ListHealthReport is the health of a list at a point in time, it can be
+// written as JSON or as a static HTML page.
+type ListHealthReport struct {
+	ListID        string                `json:"list_id"`
+	ListName      string                `json:"list_name"`
+	GeneratedAt   time.Time             `json:"generated_at"`
+	Members       int                   `json:"members"`
+	Rating        int                   `json:"rating"`
+	Rates         ListHealthRates       `json:"rates"`
+	Growth        []MonthlyGrowth       `json:"growth"`
+	Clients       []ClientShare         `json:"clients"`
+	AbuseReports  []MonthlyAbuseReports `json:"abuse_reports"`
+	RatingHistory []ListRatingPoint     `json:"rating_history"`
+}
+
+// ListHealthRates are percentages, the subscribe and unsubscribe rates are
+// the monthly averages computed by Mailchimp.
+type ListHealthRates struct {
+	SubscribeRate       float64 `json:"subscribe_rate"`
+	TargetSubscribeRate float64 `json:"target_subscribe_rate"`
+	UnsubscribeRate     float64 `json:"unsubscribe_rate"`
+	CleanedRate         float64 `json:"cleaned_rate"`
+	OpenRate            float64 `json:"open_rate"`
+	ClickRate           float64 `json:"click_rate"`
+}
+
+// MonthlyGrowth combines the growth history and the daily activity of a
+// month. NetChange is the change in subscribed members since the previous
+// month, the activity counts are only available for the last 180 days.
+type MonthlyGrowth struct {
+	Month        string `json:"month"`
+	Subscribed   int    `json:"subscribed"`
+	Unsubscribed int    `json:"unsubscribed"`
+	Cleaned      int    `json:"cleaned"`
+	Pending      int    `json:"pending"`
+	NetChange    int    `json:"net_change"`
+
+	Subs            int `json:"subs"`
+	Unsubs          int `json:"unsubs"`
+	EmailsSent      int `json:"emails_sent"`
+	UniqueOpens     int `json:"unique_opens"`
+	RecipientClicks int `json:"recipient_clicks"`
+	Bounces         int `json:"bounces"`
+}
+
+// ClientShare is an email client with its share of the members in percent
+type ClientShare struct {
+	Client  string  `json:"client"`
+	Members int     `json:"members"`
+	Share   float64 `json:"share"`
+}
+
+// MonthlyAbuseReports counts the abuse reports of a month, PerThousandSent is
+// zero when no email was sent that month.
+type MonthlyAbuseReports struct {
+	Month           string  `json:"month"`
+	Reports         int     `json:"reports"`
+	PerThousandSent float64 `json:"per_thousand_sent"`
+}
+
+// ListRatingPoint is the rating of the list when a report was generated
+type ListRatingPoint struct {
+	Month  string `json:"month"`
+	Rating int    `json:"rating"`
+}
+
+// ReadListHealthReport reads a report written with WriteJSON
+func ReadListHealthReport(r io.Reader) (*ListHealthReport, error) {
+	report := new(ListHealthReport)
+	return report, json.NewDecoder(r).Decode(report)
+}
+
+// Report fetches the list, its growth history, activity, email clients and
+// abuse reports.
+func (reporter *ListHealthReporter) Report() (*ListHealthReport, error) {
+	list, err := reporter.List.api.GetList(reporter.List.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := reporter.growthHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := list.GetActivity(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	clients, err := list.GetClients(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	abuse, err := reporter.abuseReports()
+	if err != nil {
+		return nil, err
+	}
+
+	now := reporter.now()
+	report := &ListHealthReport{
+		ListID:      list.ID,
+		ListName:    list.Name,
+		GeneratedAt: now,
+		Members:     list.Stats.MemberCount,
+		Rating:      list.ListRating,
+		Rates:       healthRates(&list.Stats),
+		Growth:      monthlyGrowth(history, activity.Activities),
+		Clients:     clientShares(clients.Clients, reporter.TopClients),
+	}
+	report.AbuseReports = monthlyAbuseReports(abuse, report.Growth)
+	report.RatingHistory = ratingHistory(reporter.Previous, now.Format("2006-01"), list.ListRating)
+
+	if reporter.Months > 0 {
+		if n := len(report.Growth); n > reporter.Months {
+			report.Growth = report.Growth[n-reporter.Months:]
+		}
+
+		// abuse reports are kept from the first month of growth kept, or
+		// over the last Months months when there is no growth history
+		from := time.Date(now.Year(), now.Month()+1-time.Month(reporter.Months), 1, 0, 0, 0, 0, now.Location()).Format("2006-01")
+		if len(report.Growth) > 0 {
+			from = report.Growth[0].Month
+		}
+		abuseReports := []MonthlyAbuseReports{}
+		for _, month := range report.AbuseReports {
+			if month.Month >= from {
+				abuseReports = append(abuseReports, month)
+			}
+		}
+		if n := len(abuseReports); n > reporter.Months {
+			abuseReports = abuseReports[n-reporter.Months:]
+		}
+		report.AbuseReports = abuseReports
+	}
+
+	return report, nil
+}
+
+func (reporter *ListHealthReporter) now() time.Time {
+	if reporter.Now == nil {
+		return time.Now()
+	}
+	return reporter.Now()
+}
+
+func (reporter *ListHealthReporter) growthHistory() ([]GrowthHistory, error) {
+	params := &ExtendedQueryParams{}
+
+	var history []GrowthHistory
+	err := pageThrough(params, func() (int, int, error) {
+		page, err := reporter.List.GetGrowthHistory(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		history = append(history, page.History...)
+		return len(page.History), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (reporter *ListHealthReporter) abuseReports() ([]AbuseReport, error) {
+	params := &ExtendedQueryParams{}
+
+	var reports []AbuseReport
+	err := pageThrough(params, func() (int, int, error) {
+		page, err := reporter.List.GetAbuseReports(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		reports = append(reports, page.Reports...)
+		return len(page.Reports), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// percent rounds 100*n/total to two decimals
+func percent(n, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(10000*n/total) / 100
+}
+
+func healthRates(stats *Stats) ListHealthRates {
+	total := stats.MemberCount + stats.UnsubscribeCount + stats.CleanedCount
+	return ListHealthRates{
+		SubscribeRate:       stats.AvgSubRate,
+		TargetSubscribeRate: stats.TargetSubRate,
+		UnsubscribeRate:     stats.AvgUnsubRate,
+		CleanedRate:         percent(float64(stats.CleanedCount), float64(total)),
+		OpenRate:            stats.OpenRate,
+		ClickRate:           stats.ClickRate,
+	}
+}
+
+// monthlyGrowth sorts the growth history by month and adds the activity of
+// each month, a month with activity but no history only has activity counts.
+func monthlyGrowth(history []GrowthHistory, activity []Activity) []MonthlyGrowth {
+	byMonth := make(map[string]*MonthlyGrowth)
+	month := func(name string) *MonthlyGrowth {
+		growth, ok := byMonth[name]
+		if !ok {
+			growth = &MonthlyGrowth{Month: name}
+			byMonth[name] = growth
+		}
+		return growth
+	}
+
+	for _, h := range history {
+		growth := month(h.Month)
+		growth.Subscribed = h.Subscribed
+		if growth.Subscribed == 0 {
+			// Older accounts only report the existing members
+			growth.Subscribed = h.Existing
+		}
+		growth.Unsubscribed = h.Unsubscribed
+		growth.Cleaned = h.Cleaned
+		growth.Pending = h.Pending
+	}
+
+	for _, a := range activity {
+		if len(a.Day) < 7 {
+			continue
+		}
+		growth := month(a.Day[:7])
+		growth.Subs += a.Subs + a.OtherAdds
+		growth.Unsubs += a.Unsubs + a.OtherRemoves
+		growth.EmailsSent += a.EmailsSent
+		growth.UniqueOpens += a.UniqueOpens
+		growth.RecipientClicks += a.RecipientClicks
+		growth.Bounces += a.HardBounce + a.SoftBounce
+	}
+
+	months := make([]MonthlyGrowth, 0, len(byMonth))
+	for _, growth := range byMonth {
+		months = append(months, *growth)
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Month < months[j].Month
+	})
+
+	for i := 1; i < len(months); i++ {
+		if months[i].Subscribed > 0 && months[i-1].Subscribed > 0 {
+			months[i].NetChange = months[i].Subscribed - months[i-1].Subscribed
+		}
+	}
+	return months
+}
+
+func clientShares(clients []Client, top int) []ClientShare {
+	total := 0
+	for _, client := range clients {
+		total += client.Members
+	}
+
+	shares := make([]ClientShare, len(clients))
+	for i, client := range clients {
+		shares[i] = ClientShare{
+			Client:  client.Client,
+			Members: client.Members,
+			Share:   percent(float64(client.Members), float64(total)),
+		}
+	}
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].Members > shares[j].Members
+	})
+
+	if top > 0 && len(shares) > top {
+		shares = shares[:top]
+	}
+	return shares
+}
+
+func monthlyAbuseReports(reports []AbuseReport, growth []MonthlyGrowth) []MonthlyAbuseReports {
+	counts := make(map[string]int)
+	for _, report := range reports {
+		if len(report.Date) >= 7 {
+			counts[report.Date[:7]]++
+		}
+	}
+
+	sent := make(map[string]int, len(growth))
+	for _, g := range growth {
+		sent[g.Month] = g.EmailsSent
+	}
+
+	months := make([]MonthlyAbuseReports, 0, len(counts))
+	for month, count := range counts {
+		months = append(months, MonthlyAbuseReports{
+			Month:           month,
+			Reports:         count,
+			PerThousandSent: percent(float64(10*count), float64(sent[month])),
+		})
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Month < months[j].Month
+	})
+	return months
+}
+
+// ratingHistory appends the current rating to the history of the previous
+// report, replacing the rating of the same month.
+func ratingHistory(previous *ListHealthReport, month string, rating int) []ListRatingPoint {
+	var history []ListRatingPoint
+	if previous != nil {
+		for _, point := range previous.RatingHistory {
+			if point.Month != month {
+				history = append(history, point)
+			}
+		}
+	}
+
+	history = append(history, ListRatingPoint{Month: month, Rating: rating})
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Month < history[j].Month
+	})
+	return history
+}
+
+// WriteJSON writes the report as indented JSON
+func (report *ListHealthReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteHTML writes the report as a self-contained HTML page
+func (report *ListHealthReport) WriteHTML(w io.Writer) error {
+	return listHealthTemplate.Execute(w, report)
+}
+
+var listHealthTemplate = template.Must(template.New("list_health").Funcs(template.FuncMap{
+	"bar": func(value, max float64) template.CSS {
+		if max <= 0 {
+			return "width: 0"
+		}
+		return template.CSS(fmt.Sprintf("width: %.1f%%", 100*value/max))
+	},
+	"maxShare": func(clients []ClientShare) float64 {
+		max := 0.0
+		for _, client := range clients {
+			max = math.Max(max, client.Share)
+		}
+		return max
+	},
+	"date": func(t time.Time) string {
+		return t.Format("January 2, 2006")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.ListName}} list health</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; color: #241c15; margin: 2em auto; max-width: 960px; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.bar { background: #007c89; height: 12px; }
+.negative { color: #c0392b; }
+.summary td { font-size: 1.2em; }
+</style>
+</head>
+<body>
+<h1>{{.ListName}}</h1>
+<p>List {{.ListID}}, generated on {{date .GeneratedAt}}</p>
+
+<h2>Summary</h2>
+<table class="summary">
+<tr><th>Members</th><th>Rating</th><th>Subscribe rate</th><th>Unsubscribe rate</th><th>Cleaned rate</th><th>Open rate</th><th>Click rate</th></tr>
+<tr><td>{{.Members}}</td><td>{{.Rating}} / 5</td><td>{{.Rates.SubscribeRate}}%</td><td>{{.Rates.UnsubscribeRate}}%</td><td>{{.Rates.CleanedRate}}%</td><td>{{.Rates.OpenRate}}%</td><td>{{.Rates.ClickRate}}%</td></tr>
+</table>
+
+<h2>Monthly growth</h2>
+<table>
+<tr><th>Month</th><th>Subscribed</th><th>Net change</th><th>Unsubscribed</th><th>Cleaned</th><th>Pending</th><th>Subs</th><th>Unsubs</th><th>Sent</th><th>Opens</th><th>Clicks</th><th>Bounces</th></tr>
+{{range .Growth}}<tr><td>{{.Month}}</td><td>{{.Subscribed}}</td><td{{if lt .NetChange 0}} class="negative"{{end}}>{{if gt .NetChange 0}}+{{end}}{{.NetChange}}</td><td>{{.Unsubscribed}}</td><td>{{.Cleaned}}</td><td>{{.Pending}}</td><td>{{.Subs}}</td><td>{{.Unsubs}}</td><td>{{.EmailsSent}}</td><td>{{.UniqueOpens}}</td><td>{{.RecipientClicks}}</td><td>{{.Bounces}}</td></tr>
+{{else}}<tr><td colspan="12">No growth history</td></tr>
+{{end}}</table>
+
+<h2>Top email clients</h2>
+<table>
+<tr><th>Client</th><th>Members</th><th>Share</th><th></th></tr>
+{{$max := maxShare .Clients}}{{range .Clients}}<tr><td>{{.Client}}</td><td>{{.Members}}</td><td>{{.Share}}%</td><td style="width: 40%"><div class="bar" style="{{bar .Share $max}}"></div></td></tr>
+{{else}}<tr><td colspan="4">No email clients</td></tr>
+{{end}}</table>
+
+<h2>Abuse reports</h2>
+<table>
+<tr><th>Month</th><th>Reports</th><th>Per 1,000 sent</th></tr>
+{{range .AbuseReports}}<tr><td>{{.Month}}</td><td>{{.Reports}}</td><td>{{.PerThousandSent}}</td></tr>
+{{else}}<tr><td colspan="3">No abuse reports</td></tr>
+{{end}}</table>
+
+<h2>List rating</h2>
+<table>
+<tr><th>Month</th><th>Rating</th></tr>
+{{range .RatingHistory}}<tr><td>{{.Month}}</td><td>{{.Rating}} / 5</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,77 @@
+package gochimp3
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListHealthReport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1", func(w http.ResponseWriter, r *http.Request) {
+		list := &ListResponse{ID: "list1", ListRating: 4}
+		list.Name = "Shop <news>"
+		list.Stats = Stats{MemberCount: 90, UnsubscribeCount: 5, CleanedCount: 5, AvgSubRate: 12, AvgUnsubRate: 1.5, OpenRate: 30}
+		writeJSON(w, list)
+	})
+	mux.HandleFunc("/lists/list1/growth-history", func(w http.ResponseWriter, r *http.Request) {
+		history := &ListOfGrownHistory{History: []GrowthHistory{
+			{Month: "2019-05", Subscribed: 90, Cleaned: 5},
+			{Month: "2019-03", Existing: 100},
+			{Month: "2019-04", Subscribed: 80},
+		}}
+		history.TotalItems = 3
+		writeJSON(w, history)
+	})
+	mux.HandleFunc("/lists/list1/activity", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfActivity{Activities: []Activity{
+			{Day: "2019-05-02", EmailsSent: 1000, Subs: 8, OtherAdds: 2, HardBounce: 1},
+			{Day: "2019-05-01", EmailsSent: 1000, Unsubs: 1, SoftBounce: 2},
+		}})
+	})
+	mux.HandleFunc("/lists/list1/clients", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfClients{Clients: []Client{{Client: "Outlook", Members: 25}, {Client: "Gmail", Members: 50}, {Client: "Apple Mail", Members: 25}}})
+	})
+	mux.HandleFunc("/lists/list1/abuse_reports", func(w http.ResponseWriter, r *http.Request) {
+		reports := &ListOfAbuseReports{Reports: []AbuseReport{{Date: "2019-05-03T10:00:00+00:00"}, {Date: "2019-05-04T10:00:00+00:00"}, {Date: "2019-02-01T10:00:00+00:00"}}}
+		reports.TotalItems = 3
+		writeJSON(w, reports)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	reporter := api.NewListResponse("list1").NewListHealthReporter()
+	reporter.Months = 2
+	reporter.TopClients = 2
+	reporter.Now = func() time.Time { return time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC) }
+	reporter.Previous = &ListHealthReport{RatingHistory: []ListRatingPoint{{Month: "2019-05", Rating: 3}, {Month: "2019-06", Rating: 2}}}
+
+	report, err := reporter.Report()
+	fatalIf(t, err)
+
+	assert.Equal(t, 90, report.Members)
+	assert.Equal(t, ListHealthRates{SubscribeRate: 12, UnsubscribeRate: 1.5, CleanedRate: 5, OpenRate: 30}, report.Rates)
+	assert.Equal(t, []MonthlyGrowth{
+		{Month: "2019-04", Subscribed: 80, NetChange: -20},
+		{Month: "2019-05", Subscribed: 90, Cleaned: 5, NetChange: 10, Subs: 10, Unsubs: 1, EmailsSent: 2000, Bounces: 3},
+	}, report.Growth)
+	assert.Equal(t, []ClientShare{{Client: "Gmail", Members: 50, Share: 50}, {Client: "Outlook", Members: 25, Share: 25}}, report.Clients)
+	assert.Equal(t, []MonthlyAbuseReports{{Month: "2019-05", Reports: 2, PerThousandSent: 1}}, report.AbuseReports)
+	assert.Equal(t, []ListRatingPoint{{Month: "2019-05", Rating: 3}, {Month: "2019-06", Rating: 4}}, report.RatingHistory)
+
+	buf := new(bytes.Buffer)
+	fatalIf(t, report.WriteJSON(buf))
+	read, err := ReadListHealthReport(buf)
+	fatalIf(t, err)
+	assert.Equal(t, report.Growth, read.Growth)
+
+	buf.Reset()
+	fatalIf(t, report.WriteHTML(buf))
+	assert.Contains(t, buf.String(), "<h1>Shop &lt;news&gt;</h1>")
+	assert.Contains(t, buf.String(), `<td class="negative">-20</td>`)
+	assert.Contains(t, buf.String(), `style="width: 100.0%"`)
+}
@@ -26,8 +26,42 @@ const (
 
 	lists_batch_subscribe_members = "/lists/%s"
 
+	locations_path = "/lists/%s/locations"
+
 	merge_fields_path = "/lists/%s/merge-fields"
-	merge_field_path  = merge_fields_path + "/%s"
+	merge_field_path  = merge_fields_path + "/%d"
+
+	signup_forms_path = "/lists/%s/signup-forms"
+
+	MERGE_FIELD_TYPE_TEXT     = "text"
+	MERGE_FIELD_TYPE_NUMBER   = "number"
+	MERGE_FIELD_TYPE_ADDRESS  = "address"
+	MERGE_FIELD_TYPE_PHONE    = "phone"
+	MERGE_FIELD_TYPE_DATE     = "date"
+	MERGE_FIELD_TYPE_URL      = "url"
+	MERGE_FIELD_TYPE_IMAGEURL = "imageurl"
+	MERGE_FIELD_TYPE_RADIO    = "radio"
+	MERGE_FIELD_TYPE_DROPDOWN = "dropdown"
+	MERGE_FIELD_TYPE_BIRTHDAY = "birthday"
+	MERGE_FIELD_TYPE_ZIP      = "zip"
+
+	PHONE_FORMAT_US            = "US"
+	PHONE_FORMAT_INTERNATIONAL = "none"
+
+	DATE_FORMAT_MONTH_FIRST = "MM/DD/YYYY"
+	DATE_FORMAT_DAY_FIRST   = "DD/MM/YYYY"
+
+	BIRTHDAY_FORMAT_MONTH_FIRST = "MM/DD"
+	BIRTHDAY_FORMAT_DAY_FIRST   = "DD/MM"
+
+	INTEREST_CATEGORY_TYPE_CHECKBOXES = "checkboxes"
+	INTEREST_CATEGORY_TYPE_DROPDOWN   = "dropdown"
+	INTEREST_CATEGORY_TYPE_RADIO      = "radio"
+	INTEREST_CATEGORY_TYPE_HIDDEN     = "hidden"
+
+	SIGNUP_FORM_SECTION_SIGNUP_MESSAGE         = "signup_message"
+	SIGNUP_FORM_SECTION_UNSUB_MESSAGE          = "unsub_message"
+	SIGNUP_FORM_SECTION_SIGNUP_THANK_YOU_TITLE = "signup_thank_you_title"
 )
 
 type ListQueryParams struct {
@@ -65,6 +99,28 @@ type ListCreationRequest struct {
 	NotifyOnUnsubscribe string           `json:"notify_on_unsubscribe"`
 	EmailTypeOption     bool             `json:"email_type_option"`
 	Visibility          string           `json:"visibility"`
+
+	// DoubleOptin requires new subscribers to confirm their email address
+	DoubleOptin bool `json:"double_optin"`
+
+	// MarketingPermissions enables the GDPR fields of the list
+	MarketingPermissions bool `json:"marketing_permissions"`
+}
+
+// ListUpdateRequest only changes the fields which are set, see Bool and
+// String. Contact and CampaignDefaults are replaced as a whole.
+type ListUpdateRequest struct {
+	Name                 *string           `json:"name,omitempty"`
+	Contact              *Contact          `json:"contact,omitempty"`
+	PermissionReminder   *string           `json:"permission_reminder,omitempty"`
+	UseArchiveBar        *bool             `json:"use_archive_bar,omitempty"`
+	CampaignDefaults     *CampaignDefaults `json:"campaign_defaults,omitempty"`
+	NotifyOnSubscribe    *string           `json:"notify_on_subscribe,omitempty"`
+	NotifyOnUnsubscribe  *string           `json:"notify_on_unsubscribe,omitempty"`
+	EmailTypeOption      *bool             `json:"email_type_option,omitempty"`
+	Visibility           *string           `json:"visibility,omitempty"`
+	DoubleOptin          *bool             `json:"double_optin,omitempty"`
+	MarketingPermissions *bool             `json:"marketing_permissions,omitempty"`
 }
 
 type ListResponse struct {
@@ -80,6 +136,9 @@ type ListResponse struct {
 	Modules           []string `json:"modules"`
 	Stats             Stats    `json:"stats"`
 
+	// HasWelcome is set when a welcome automation is connected to the list
+	HasWelcome bool `json:"has_welcome"`
+
 	api *API
 }
 
@@ -158,7 +217,8 @@ func (api *API) CreateList(body *ListCreationRequest) (*ListResponse, error) {
 	return response, api.Request("POST", lists_path, nil, body, response)
 }
 
-func (api *API) UpdateList(id string, body *ListCreationRequest) (*ListResponse, error) {
+// UpdateList changes the fields of the list set in body
+func (api *API) UpdateList(id string, body *ListUpdateRequest) (*ListResponse, error) {
 	endpoint := fmt.Sprintf(single_list_path, id)
 
 	response := new(ListResponse)
@@ -301,6 +361,15 @@ type GrowthHistory struct {
 	Imports  int    `json:"imports"`
 	OptIns   int    `json:"optins"`
 
+	// Member counts at the end of the month by status
+	Subscribed    int `json:"subscribed"`
+	Unsubscribed  int `json:"unsubscribed"`
+	Reconfirm     int `json:"reconfirm"`
+	Cleaned       int `json:"cleaned"`
+	Pending       int `json:"pending"`
+	Deleted       int `json:"deleted"`
+	Transactional int `json:"transactional"`
+
 	withLinks
 }
 
@@ -326,6 +395,37 @@ func (list *ListResponse) GetGrowthHistoryForMonth(month string, params *BasicQu
 	return response, list.api.Request("GET", endpoint, params, nil, response)
 }
 
+// ------------------------------------------------------------------------------------------------
+// Locations
+// ------------------------------------------------------------------------------------------------
+
+type ListOfLocations struct {
+	baseList
+
+	ListID    string     `json:"list_id"`
+	Locations []Location `json:"locations"`
+}
+
+// Location is the country of the members of a list, Percent is the share of
+// the members in that country.
+type Location struct {
+	Country string  `json:"country"`
+	CC      string  `json:"cc"`
+	Percent float64 `json:"percent"`
+	Total   int     `json:"total"`
+}
+
+func (list *ListResponse) GetLocations(params *BasicQueryParams) (*ListOfLocations, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(locations_path, list.ID)
+	response := new(ListOfLocations)
+
+	return response, list.api.Request("GET", endpoint, params, nil, response)
+}
+
 // ------------------------------------------------------------------------------------------------
 // Interest Categories
 // ------------------------------------------------------------------------------------------------
@@ -353,6 +453,10 @@ type InterestCategory struct {
 }
 
 func (interestCatgory *InterestCategory) CanMakeRequest() error {
+	if interestCatgory.ListID == "" {
+		return errors.New("No ListID provided on interest category")
+	}
+
 	if interestCatgory.ID == "" {
 		return errors.New("No ID provided on interest category")
 	}
@@ -417,8 +521,8 @@ func (list *ListResponse) CreateInterestCategory(body *InterestCategoryRequest)
 }
 
 func (list *ListResponse) UpdateInterestCategory(id string, body *InterestCategoryRequest) (*InterestCategory, error) {
-	if list.ID == "" {
-		return nil, errors.New("No ID provided on list")
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
 	}
 
 	endpoint := fmt.Sprintf(single_interest_category_path, list.ID, id)
@@ -429,8 +533,8 @@ func (list *ListResponse) UpdateInterestCategory(id string, body *InterestCatego
 }
 
 func (list *ListResponse) DeleteInterestCategory(id string) (bool, error) {
-	if list.ID == "" {
-		return false, errors.New("No ID provided on list")
+	if err := list.CanMakeRequest(); err != nil {
+		return false, err
 	}
 
 	endpoint := fmt.Sprintf(single_interest_category_path, list.ID, id)
@@ -496,6 +600,26 @@ func (interestCategory *InterestCategory) CreateInterest(body *InterestRequest)
 	return response, interestCategory.api.Request("POST", endpoint, nil, body, response)
 }
 
+func (interestCategory *InterestCategory) UpdateInterest(id string, body *InterestRequest) (*Interest, error) {
+	if err := interestCategory.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(single_interest_path, interestCategory.ListID, interestCategory.ID, id)
+	response := new(Interest)
+
+	return response, interestCategory.api.Request("PATCH", endpoint, nil, body, response)
+}
+
+func (interestCategory *InterestCategory) DeleteInterest(id string) (bool, error) {
+	if err := interestCategory.CanMakeRequest(); err != nil {
+		return false, err
+	}
+
+	endpoint := fmt.Sprintf(single_interest_path, interestCategory.ListID, interestCategory.ID, id)
+	return interestCategory.api.RequestOk("DELETE", endpoint)
+}
+
 // ------------------------------------------------------------------------------------------------
 // Batch subscribe list members
 // ------------------------------------------------------------------------------------------------
@@ -545,10 +669,13 @@ type MergeFieldsParams struct {
 	Required bool   `json:"required"`
 }
 
-type MergeFieldParams struct {
-	BasicQueryParams
-
-	MergeID string `json:"_"`
+func (q *MergeFieldsParams) Params() map[string]string {
+	m := q.ExtendedQueryParams.Params()
+	m["type"] = q.Type
+	if q.Required {
+		m["required"] = "true"
+	}
+	return m
 }
 
 type ListOfMergeFields struct {
@@ -574,12 +701,69 @@ type MergeField struct {
 	withLinks
 }
 
+// MergeFieldOptions is the wire format of the options of every merge field
+// type. Only the options relevant to the field's type are set, see the typed
+// option structs below to build them.
 type MergeFieldOptions struct {
-	DefaultCountry int      `json:"default_Country"`
-	PhoneFormat    string   `json:"phone_format"`
-	DateFormat     string   `json:"date_format"`
+	DefaultCountry int      `json:"default_country,omitempty"`
+	PhoneFormat    string   `json:"phone_format,omitempty"`
+	DateFormat     string   `json:"date_format,omitempty"`
 	Choices        []string `json:"choices,omitempty"`
-	Size           int      `json:"size"`
+	Size           int      `json:"size,omitempty"`
+}
+
+// MergeFieldTypeOptions is implemented by the options of each merge field type
+type MergeFieldTypeOptions interface {
+	MergeFieldOptions() MergeFieldOptions
+}
+
+// TextOptions are the options of a text merge field
+type TextOptions struct {
+	// The length of the input field on the signup form.
+	Size int
+}
+
+func (o TextOptions) MergeFieldOptions() MergeFieldOptions {
+	return MergeFieldOptions{Size: o.Size}
+}
+
+// AddressOptions are the options of an address merge field
+type AddressOptions struct {
+	// The ID of the country selected by default on the signup form.
+	DefaultCountry int
+}
+
+func (o AddressOptions) MergeFieldOptions() MergeFieldOptions {
+	return MergeFieldOptions{DefaultCountry: o.DefaultCountry}
+}
+
+// PhoneOptions are the options of a phone merge field
+type PhoneOptions struct {
+	// One of PHONE_FORMAT_*
+	PhoneFormat string
+}
+
+func (o PhoneOptions) MergeFieldOptions() MergeFieldOptions {
+	return MergeFieldOptions{PhoneFormat: o.PhoneFormat}
+}
+
+// DateOptions are the options of a date or birthday merge field
+type DateOptions struct {
+	// One of DATE_FORMAT_* for dates or BIRTHDAY_FORMAT_* for birthdays
+	DateFormat string
+}
+
+func (o DateOptions) MergeFieldOptions() MergeFieldOptions {
+	return MergeFieldOptions{DateFormat: o.DateFormat}
+}
+
+// ChoiceOptions are the options of a dropdown or radio merge field
+type ChoiceOptions struct {
+	Choices []string
+}
+
+func (o ChoiceOptions) MergeFieldOptions() MergeFieldOptions {
+	return MergeFieldOptions{Choices: o.Choices}
 }
 
 type MergeFieldRequest struct {
@@ -589,29 +773,46 @@ type MergeFieldRequest struct {
 	// The name of the merge field.
 	Name string `json:"name"`
 
-	// The type for the merge field.
-	// Possible Values: text, number, address, phone, date, url, image, url, radio, dropdown, birthday, zip
-	Type string `json:"type"`
+	// The type for the merge field. It is required on creation and can not be changed afterwards.
+	// Possible Values: one of MERGE_FIELD_TYPE_*
+	Type string `json:"type,omitempty"`
 
-	// The boolean value if the merge field is required.
-	Required bool `json:"required"`
+	// The boolean value if the merge field is required. Left unchanged by an
+	// update when nil.
+	Required *bool `json:"required,omitempty"`
 
 	// The default value for the merge field if null.
 	DefaultValue string `json:"default_value"`
 
-	// Whether the merge field is displayed on the signup form.
-	Public bool `json:"public"`
+	// Whether the merge field is displayed on the signup form. Left unchanged
+	// by an update when nil.
+	Public *bool `json:"public,omitempty"`
 
-	// The order that the merge field displays on the list signup form.
-	DisplayOrder int `json:"display_order"`
+	// The order that the merge field displays on the list signup form. Left
+	// unchanged by an update when nil.
+	DisplayOrder *int `json:"display_order,omitempty"`
 
-	// The order that the merge field displays on the list signup form.
+	// The options of the merge field, depending on its type.
 	Options MergeFieldOptions `json:"options"`
 
 	// Extra text to help the subscriber fill out the form.
 	HelpText string `json:"help_text"`
 }
 
+// NewMergeFieldRequest builds the request for a merge field of the given type,
+// opts may be nil for types without options.
+func NewMergeFieldRequest(tag, name, fieldType string, opts MergeFieldTypeOptions) *MergeFieldRequest {
+	req := &MergeFieldRequest{
+		Tag:  tag,
+		Name: name,
+		Type: fieldType,
+	}
+	if opts != nil {
+		req.Options = opts.MergeFieldOptions()
+	}
+	return req
+}
+
 func (list *ListResponse) GetMergeFields(params *MergeFieldsParams) (*ListOfMergeFields, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return nil, err
@@ -623,12 +824,12 @@ func (list *ListResponse) GetMergeFields(params *MergeFieldsParams) (*ListOfMerg
 	return response, list.api.Request("GET", endpoint, params, nil, response)
 }
 
-func (list *ListResponse) GetMergeField(params *MergeFieldParams) (*MergeField, error) {
+func (list *ListResponse) GetMergeField(id int, params *BasicQueryParams) (*MergeField, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return nil, err
 	}
 
-	endpoint := fmt.Sprintf(merge_field_path, list.ID, params.MergeID)
+	endpoint := fmt.Sprintf(merge_field_path, list.ID, id)
 	response := new(MergeField)
 
 	return response, list.api.Request("GET", endpoint, params, nil, response)
@@ -644,3 +845,97 @@ func (list *ListResponse) CreateMergeField(body *MergeFieldRequest) (*MergeField
 
 	return response, list.api.Request("POST", endpoint, nil, body, response)
 }
+
+func (list *ListResponse) UpdateMergeField(id int, body *MergeFieldRequest) (*MergeField, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(merge_field_path, list.ID, id)
+	response := new(MergeField)
+
+	return response, list.api.Request("PATCH", endpoint, nil, body, response)
+}
+
+func (list *ListResponse) DeleteMergeField(id int) (bool, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return false, err
+	}
+
+	endpoint := fmt.Sprintf(merge_field_path, list.ID, id)
+	return list.api.RequestOk("DELETE", endpoint)
+}
+
+// ------------------------------------------------------------------------------------------------
+// Signup Forms
+// ------------------------------------------------------------------------------------------------
+
+type ListOfSignupForms struct {
+	baseList
+	SignupForms []SignupForm `json:"signup_forms"`
+}
+
+// SignupForm customizes the hosted signup form of a list. When customizing,
+// only the sections and styles which are sent are changed.
+type SignupForm struct {
+	Header   *SignupFormHeader   `json:"header,omitempty"`
+	Contents []SignupFormContent `json:"contents,omitempty"`
+	Styles   []SignupFormStyle   `json:"styles,omitempty"`
+
+	// Response
+	SignupFormURL string `json:"signup_form_url,omitempty"`
+	ListID        string `json:"list_id,omitempty"`
+}
+
+type SignupFormHeader struct {
+	ImageURL         string `json:"image_url,omitempty"`
+	Text             string `json:"text,omitempty"`
+	ImageWidth       string `json:"image_width,omitempty"`
+	ImageHeight      string `json:"image_height,omitempty"`
+	ImageAlt         string `json:"image_alt,omitempty"`
+	ImageLink        string `json:"image_link,omitempty"`
+	ImageAlign       string `json:"image_align,omitempty"`
+	ImageBorderWidth string `json:"image_border_width,omitempty"`
+	ImageBorderStyle string `json:"image_border_style,omitempty"`
+	ImageBorderColor string `json:"image_border_color,omitempty"`
+	ImageTarget      string `json:"image_target,omitempty"`
+}
+
+// SignupFormContent is the text of a section, one of SIGNUP_FORM_SECTION_*
+type SignupFormContent struct {
+	Section string `json:"section"`
+	Value   string `json:"value"`
+}
+
+// SignupFormStyle applies CSS properties to the elements matching Selector
+type SignupFormStyle struct {
+	Selector string                  `json:"selector"`
+	Options  []SignupFormStyleOption `json:"options"`
+}
+
+type SignupFormStyleOption struct {
+	Property string `json:"property"`
+	Value    string `json:"value"`
+}
+
+func (list *ListResponse) GetSignupForms() (*ListOfSignupForms, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(signup_forms_path, list.ID)
+	response := new(ListOfSignupForms)
+
+	return response, list.api.Request("GET", endpoint, nil, nil, response)
+}
+
+func (list *ListResponse) CustomizeSignupForm(body *SignupForm) (*SignupForm, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(signup_forms_path, list.ID)
+	response := new(SignupForm)
+
+	return response, list.api.Request("POST", endpoint, nil, body, response)
+}
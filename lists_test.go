@@ -0,0 +1,48 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateList(t *testing.T) {
+	var body map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		writeJSON(w, map[string]interface{}{"id": "list1", "name": body["name"], "double_optin": true, "has_welcome": true})
+	})
+	mux.HandleFunc("/lists/list1/signup-forms", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			writeJSON(w, map[string]interface{}{"signup_forms": []map[string]interface{}{{"signup_form_url": "http://eepurl.com/x"}}, "total_items": 1})
+			return
+		}
+		form := SignupForm{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&form))
+		assert.Nil(t, form.Header)
+		writeJSON(w, form)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	list, err := api.UpdateList("list1", &ListUpdateRequest{Name: String("Newsletter"), DoubleOptin: Bool(true), UseArchiveBar: Bool(false)})
+	fatalIf(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "Newsletter", "double_optin": true, "use_archive_bar": false}, body)
+	assert.Equal(t, "Newsletter", list.Name)
+	assert.True(t, list.DoubleOptin)
+	assert.True(t, list.HasWelcome)
+
+	forms, err := list.GetSignupForms()
+	fatalIf(t, err)
+	assert.Equal(t, "http://eepurl.com/x", forms.SignupForms[0].SignupFormURL)
+
+	form, err := list.CustomizeSignupForm(&SignupForm{Contents: []SignupFormContent{{Section: SIGNUP_FORM_SECTION_SIGNUP_MESSAGE, Value: "Hi"}}})
+	fatalIf(t, err)
+	assert.Equal(t, "Hi", form.Contents[0].Value)
+}
@@ -0,0 +1,214 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Find returns the permission whose text matches, ignoring case and
+// surrounding whitespace.
+func (perms MarketingPermissions) Find(text string) (*MarketingPermission, bool) {
+	text = strings.TrimSpace(text)
+	for i := range perms {
+		if strings.EqualFold(strings.TrimSpace(perms[i].Text), text) {
+			return &perms[i], true
+		}
+	}
+	return nil, false
+}
+
+// WithConsent returns the permissions to send to grant or revoke consent,
+// keyed by permission text.
+func (perms MarketingPermissions) WithConsent(consent map[string]bool) (MarketingPermissions, error) {
+	changes := make(MarketingPermissions, 0, len(consent))
+	for text, enabled := range consent {
+		permission, ok := perms.Find(text)
+		if !ok {
+			return nil, fmt.Errorf("No marketing permission with text %q", text)
+		}
+		changes = append(changes, MarketingPermission{
+			MarketingPermissionID: permission.MarketingPermissionID,
+			Text:                  permission.Text,
+			Enabled:               enabled,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].MarketingPermissionID < changes[j].MarketingPermissionID
+	})
+	return changes, nil
+}
+
+// GetMarketingPermissions returns the marketing permissions of a list with
+// GDPR fields enabled. Mailchimp only exposes them on members, so they are
+// read from the first member of the list and can't be discovered on a list
+// without members, their IDs must then be known beforehand.
+func (list *ListResponse) GetMarketingPermissions() (MarketingPermissions, error) {
+	params := &MemberQueryParams{}
+	params.Count = 1
+	params.Fields = []string{"members.marketing_permissions"}
+
+	members, err := list.GetMembers(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(members.Members) == 0 {
+		return nil, errors.New("The list has no member to read marketing permissions from, their IDs must be provided")
+	}
+
+	perms := members.Members[0].MarketingPermissions
+	for i := range perms {
+		perms[i].Enabled = false
+	}
+	return perms, nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// Consent
+// ------------------------------------------------------------------------------------------------
+
+// ConsentAuditEntry records a change of consent to a marketing permission.
+// Previous is nil when the member did not exist before.
+type ConsentAuditEntry struct {
+	Timestamp             time.Time `json:"timestamp"`
+	ListID                string    `json:"list_id"`
+	EmailAddress          string    `json:"email_address"`
+	MarketingPermissionID string    `json:"marketing_permission_id"`
+	Text                  string    `json:"text"`
+	Enabled               bool      `json:"enabled"`
+	Previous              *bool     `json:"previous"`
+}
+
+// ConsentAuditLog stores consent changes, see JSONConsentAuditLog
+type ConsentAuditLog interface {
+	Record(entry ConsentAuditEntry) error
+}
+
+// JSONConsentAuditLog writes each entry as a line of JSON
+type JSONConsentAuditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewJSONConsentAuditLog(w io.Writer) *JSONConsentAuditLog {
+	return &JSONConsentAuditLog{w: w}
+}
+
+func (auditLog *JSONConsentAuditLog) Record(entry ConsentAuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	_, err = auditLog.w.Write(append(data, '\n'))
+	return err
+}
+
+// UpdateConsent grants or revokes the marketing permissions of an existing
+// member of the list, keyed by permission text, and records every change in
+// audit.
+func (list *ListResponse) UpdateConsent(email string, consent map[string]bool, audit ConsentAuditLog) (*Member, error) {
+	current, err := list.GetMemberByEmail(email, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := current.MarketingPermissions.WithConsent(consent)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(single_member_path, list.ID, current.ID)
+	response := new(Member)
+	response.api = list.api
+
+	body := struct {
+		MarketingPermissions MarketingPermissions `json:"marketing_permissions"`
+	}{changes}
+
+	if err := list.api.Request("PATCH", endpoint, nil, &body, response); err != nil {
+		return nil, err
+	}
+
+	return response, list.auditConsent(audit, response.EmailAddress, current.MarketingPermissions, changes)
+}
+
+// SubscribeWithConsent adds or updates the member described by body with the
+// marketing permissions given by text, e.g. from a signup form, and records
+// every change in audit. The permissions of a new member are looked up with
+// GetMarketingPermissions, unless body.MarketingPermissions already lists
+// them, which is needed while the list has no members.
+func (list *ListResponse) SubscribeWithConsent(body *MemberRequest, consent map[string]bool, audit ConsentAuditLog) (*Member, error) {
+	var previous, available MarketingPermissions
+
+	current, err := list.GetMemberByEmail(body.EmailAddress, nil)
+	switch {
+	case err == nil:
+		previous = current.MarketingPermissions
+		available = previous
+	case isNotFound(err) && body.MarketingPermissions != nil:
+		available = *body.MarketingPermissions
+	case isNotFound(err):
+		if available, err = list.GetMarketingPermissions(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	changes, err := available.WithConsent(consent)
+	if err != nil {
+		return nil, err
+	}
+
+	withConsent := *body
+	withConsent.MarketingPermissions = &changes
+
+	member, err := list.UpsertMemberByEmail(body.EmailAddress, &withConsent)
+	if err != nil {
+		return nil, err
+	}
+
+	return member, list.auditConsent(audit, member.EmailAddress, previous, changes)
+}
+
+func (list *ListResponse) auditConsent(audit ConsentAuditLog, email string, previous, changes MarketingPermissions) error {
+	if audit == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for _, change := range changes {
+		entry := ConsentAuditEntry{
+			Timestamp:             now,
+			ListID:                list.ID,
+			EmailAddress:          email,
+			MarketingPermissionID: change.MarketingPermissionID,
+			Text:                  change.Text,
+			Enabled:               change.Enabled,
+		}
+
+		for _, before := range previous {
+			if before.MarketingPermissionID == change.MarketingPermissionID {
+				enabled := before.Enabled
+				entry.Previous = &enabled
+			}
+		}
+
+		if entry.Previous != nil && *entry.Previous == change.Enabled {
+			continue
+		}
+		if err := audit.Record(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
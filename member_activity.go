@@ -0,0 +1,182 @@
+package gochimp3
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MemberActivityAction is the type of an action of a member, as found in
+// MemberActivity.Action and MemberActivityFeedItem.ActivityType.
+type MemberActivityAction string
+
+const (
+	MEMBER_ACTIVITY_OPEN   MemberActivityAction = "open"
+	MEMBER_ACTIVITY_CLICK  MemberActivityAction = "click"
+	MEMBER_ACTIVITY_BOUNCE MemberActivityAction = "bounce"
+	MEMBER_ACTIVITY_UNSUB  MemberActivityAction = "unsub"
+	MEMBER_ACTIVITY_SENT   MemberActivityAction = "sent"
+	MEMBER_ACTIVITY_ECOMM  MemberActivityAction = "ecomm"
+	MEMBER_ACTIVITY_NOTE   MemberActivityAction = "note"
+	MEMBER_ACTIVITY_EVENT  MemberActivityAction = "event"
+)
+
+// engagementActions are the actions showing a member reads the campaigns
+var engagementActions = []MemberActivityAction{MEMBER_ACTIVITY_OPEN, MEMBER_ACTIVITY_CLICK}
+
+// Time parses the timestamp of the action
+func (activity *MemberActivity) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339, activity.Timestamp)
+}
+
+// ------------------------------------------------------------------------------------------------
+// Activity feed
+// ------------------------------------------------------------------------------------------------
+
+type ListOfMemberActivityFeed struct {
+	baseList
+
+	EmailID  string             `json:"email_id"`
+	ListID   string             `json:"list_id"`
+	Activity MemberActivityFeed `json:"activity"`
+}
+
+// MemberActivityFeedItem is an entry of the activity feed, the fields set
+// depend on ActivityType.
+type MemberActivityFeedItem struct {
+	ActivityType       MemberActivityAction `json:"activity_type"`
+	CreatedAtTimestamp string               `json:"created_at_timestamp"`
+	CampaignID         string               `json:"campaign_id,omitempty"`
+	CampaignTitle      string               `json:"campaign_title,omitempty"`
+	LinkClicked        string               `json:"link_clicked,omitempty"`
+	BounceType         string               `json:"bounce_type,omitempty"`
+	UnsubscribeReason  string               `json:"unsubscribe_reason,omitempty"`
+	NoteID             int                  `json:"note_id,omitempty"`
+	NoteText           string               `json:"note_text,omitempty"`
+	EventName          string               `json:"event_name,omitempty"`
+}
+
+// Time parses the timestamp of the activity
+func (item *MemberActivityFeedItem) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339, item.CreatedAtTimestamp)
+}
+
+// MemberActivityFeedParams filters the activity feed by type of activity
+type MemberActivityFeedParams struct {
+	ExtendedQueryParams
+
+	ActivityFilters []MemberActivityAction
+}
+
+func (q *MemberActivityFeedParams) Params() map[string]string {
+	m := q.ExtendedQueryParams.Params()
+
+	filters := make([]string, len(q.ActivityFilters))
+	for i, action := range q.ActivityFilters {
+		filters[i] = string(action)
+	}
+	m["activity_filters"] = strings.Join(filters, ",")
+	return m
+}
+
+// GetActivityFeed returns a page of the complete activity history of the member
+func (mem *Member) GetActivityFeed(params *MemberActivityFeedParams) (*ListOfMemberActivityFeed, error) {
+	if err := mem.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(member_activity_feed_path, mem.ListID, mem.ID)
+	response := new(ListOfMemberActivityFeed)
+
+	return response, mem.api.Request("GET", endpoint, params, nil, response)
+}
+
+// GetAllActivityFeed pages through the activity feed of the member, keeping
+// only the given actions when there are any.
+func (mem *Member) GetAllActivityFeed(actions ...MemberActivityAction) (MemberActivityFeed, error) {
+	params := &MemberActivityFeedParams{ActivityFilters: actions}
+
+	var feed MemberActivityFeed
+	err := pageThrough(&params.ExtendedQueryParams, func() (int, int, error) {
+		page, err := mem.GetActivityFeed(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		feed = append(feed, page.Activity...)
+		return len(page.Activity), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// LastEngagedAt returns when the member last opened or clicked a campaign,
+// the zero time if it never did.
+func (mem *Member) LastEngagedAt() (time.Time, error) {
+	feed, err := mem.GetAllActivityFeed(engagementActions...)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return feed.LastEngagedAt(), nil
+}
+
+// MemberActivityFeed is a list of activities, in any order
+type MemberActivityFeed []MemberActivityFeedItem
+
+// Filter keeps the activities of the given types
+func (feed MemberActivityFeed) Filter(actions ...MemberActivityAction) MemberActivityFeed {
+	filtered := MemberActivityFeed{}
+	for _, item := range feed {
+		for _, action := range actions {
+			if item.ActivityType == action {
+				filtered = append(filtered, item)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// Between keeps the activities from from included to to excluded, a zero
+// bound is ignored. Activities with an invalid timestamp are dropped.
+func (feed MemberActivityFeed) Between(from, to time.Time) MemberActivityFeed {
+	filtered := MemberActivityFeed{}
+	for _, item := range feed {
+		t, err := item.Time()
+		if err != nil {
+			continue
+		}
+		if !from.IsZero() && t.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !t.Before(to) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+// Since keeps the activities of the last d
+func (feed MemberActivityFeed) Since(d time.Duration) MemberActivityFeed {
+	return feed.Between(time.Now().Add(-d), time.Time{})
+}
+
+// Last returns the time of the most recent activity, the zero time for an
+// empty feed.
+func (feed MemberActivityFeed) Last() time.Time {
+	var last time.Time
+	for _, item := range feed {
+		if t, err := item.Time(); err == nil && t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// LastEngagedAt returns the time of the most recent open or click
+func (feed MemberActivityFeed) LastEngagedAt() time.Time {
+	return feed.Filter(engagementActions...).Last()
+}
@@ -0,0 +1,283 @@
+package gochimp3
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	EXPORT_FORMAT_CSV     = "csv"
+	EXPORT_FORMAT_JSONL   = "jsonl"
+	EXPORT_FORMAT_PARQUET = "parquet"
+)
+
+// memberExportField is a column taken from the member itself
+type memberExportField struct {
+	name  string
+	value func(*Member) string
+}
+
+var memberExportFields = []memberExportField{
+	{"id", func(m *Member) string { return m.ID }},
+	{"email_address", func(m *Member) string { return m.EmailAddress }},
+	{"unique_email_id", func(m *Member) string { return m.UniqueEmailID }},
+	{"email_type", func(m *Member) string { return m.EmailType }},
+	{"status", func(m *Member) string { return m.Status }},
+	{"language", func(m *Member) string { return m.Language }},
+	{"vip", func(m *Member) string { return strconv.FormatBool(m.VIP) }},
+	{"member_rating", func(m *Member) string { return strconv.Itoa(m.MemberRating) }},
+	{"timestamp_signup", func(m *Member) string { return m.TimestampSignup }},
+	{"timestamp_opt", func(m *Member) string { return m.TimestampOpt }},
+	{"last_changed", func(m *Member) string { return m.LastChanged }},
+	{"stats.avg_open_rate", func(m *Member) string { return formatFloat(m.Stats.AvgOpenRate) }},
+	{"stats.avg_click_rate", func(m *Member) string { return formatFloat(m.Stats.AvgClickRate) }},
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// memberRowWriter writes the rows of an export in one format
+type memberRowWriter interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (writer *csvRowWriter) WriteRow(values []string) error {
+	return writer.w.Write(values)
+}
+
+func (writer *csvRowWriter) Close() error {
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+type jsonlRowWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (writer *jsonlRowWriter) WriteRow(values []string) error {
+	row := make(map[string]string, len(values))
+	for i, value := range values {
+		row[writer.columns[i]] = value
+	}
+	return writer.encoder.Encode(row)
+}
+
+func (writer *jsonlRowWriter) Close() error {
+	return nil
+}
+
+// MemberExporter writes every member of a list as a flat row: the member
+// fields, a merge_fields.TAG column per merge field, an interests.Category >
+// Interest column per interest and a tags column with the comma separated
+// tag names. All values are strings. Parquet column names have their dots
+// and other punctuation replaced with _, e.g. merge_fields_TAG.
+type MemberExporter struct {
+	List   *ListResponse
+	Format string
+
+	// Params filters the members, e.g. with Status. When Fields is set only
+	// the matching columns are exported, the merge fields, interests and
+	// tags are selected with merge_fields, interests and tags.
+	Params *MemberQueryParams
+
+	// ParquetRowGroupSize is the number of rows buffered per row group
+	ParquetRowGroupSize int
+}
+
+func (list *ListResponse) NewMemberExporter(format string) *MemberExporter {
+	return &MemberExporter{List: list, Format: format}
+}
+
+// memberExportColumns are the columns of an export and how to fill them
+type memberExportColumns struct {
+	names       []string
+	fields      []memberExportField
+	mergeTags   []string
+	interestIDs []string
+	tags        bool
+}
+
+// Export streams the members to w one page at a time and returns how many
+// were written.
+func (exporter *MemberExporter) Export(w io.Writer) (int, error) {
+	params := MemberQueryParams{}
+	if exporter.Params != nil {
+		params = *exporter.Params
+	}
+
+	columns, err := exporter.columns(params.Fields)
+	if err != nil {
+		return 0, err
+	}
+
+	writer, err := exporter.rowWriter(w, columns.names)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(params.Fields) > 0 {
+		params.Fields = exportQueryFields(params.Fields)
+	}
+
+	count := 0
+	err = exporter.List.EachMember(&params, func(member *Member) error {
+		count++
+		return writer.WriteRow(columns.row(member))
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, writer.Close()
+}
+
+func (exporter *MemberExporter) rowWriter(w io.Writer, columns []string) (memberRowWriter, error) {
+	switch exporter.Format {
+	case EXPORT_FORMAT_CSV, "":
+		writer := &csvRowWriter{w: csv.NewWriter(w)}
+		return writer, writer.WriteRow(columns)
+	case EXPORT_FORMAT_JSONL:
+		return &jsonlRowWriter{encoder: json.NewEncoder(w), columns: columns}, nil
+	case EXPORT_FORMAT_PARQUET:
+		return newParquetWriter(w, columns, exporter.ParquetRowGroupSize)
+	}
+	return nil, fmt.Errorf("Unknown export format %q", exporter.Format)
+}
+
+// columns lists the merge fields and interests of the list to build the
+// columns selected by fields, all of them when it is empty.
+func (exporter *MemberExporter) columns(fields []string) (*memberExportColumns, error) {
+	selected := func(name string) bool {
+		if len(fields) == 0 {
+			return true
+		}
+		for _, field := range fields {
+			field = strings.TrimPrefix(field, "members.")
+			if field == name || strings.HasPrefix(name, field+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	columns := &memberExportColumns{}
+	for _, field := range memberExportFields {
+		if selected(field.name) {
+			columns.fields = append(columns.fields, field)
+			columns.names = append(columns.names, field.name)
+		}
+	}
+
+	if selected("merge_fields") {
+		mergeFields, err := exporter.List.GetAllMergeFields()
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(mergeFields, func(i, j int) bool {
+			return mergeFields[i].DisplayOrder < mergeFields[j].DisplayOrder
+		})
+		for _, field := range mergeFields {
+			columns.mergeTags = append(columns.mergeTags, field.Tag)
+			columns.names = append(columns.names, "merge_fields."+field.Tag)
+		}
+	}
+
+	if selected("interests") {
+		interests, err := exporter.List.interestNames()
+		if err != nil {
+			return nil, err
+		}
+		for _, interest := range interests {
+			columns.interestIDs = append(columns.interestIDs, interest.id)
+			columns.names = append(columns.names, "interests."+interest.name)
+		}
+	}
+
+	if selected("tags") {
+		columns.tags = true
+		columns.names = append(columns.names, "tags")
+	}
+
+	return columns, nil
+}
+
+func (columns *memberExportColumns) row(member *Member) []string {
+	row := make([]string, 0, len(columns.names))
+	for _, field := range columns.fields {
+		row = append(row, field.value(member))
+	}
+	for _, tag := range columns.mergeTags {
+		row = append(row, formatMergeFieldValue(member.MergeFields[tag]))
+	}
+	for _, id := range columns.interestIDs {
+		row = append(row, strconv.FormatBool(member.Interests[id]))
+	}
+	if columns.tags {
+		names := make([]string, len(member.Tags))
+		for i, tag := range member.Tags {
+			names[i] = tag.Name
+		}
+		row = append(row, strings.Join(names, ","))
+	}
+	return row
+}
+
+// exportQueryFields converts the selected columns to the fields of the
+// members request, keeping the total needed to page.
+func exportQueryFields(fields []string) []string {
+	query := []string{"total_items"}
+	for _, field := range fields {
+		if !strings.HasPrefix(field, "members.") {
+			field = "members." + field
+		}
+		query = append(query, field)
+	}
+	return query
+}
+
+func formatMergeFieldValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return formatFloat(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// interestName is an interest with its "Category > Interest" name
+type interestName struct {
+	id   string
+	name string
+}
+
+// interestNames lists the interests of every interest category of the list
+func (list *ListResponse) interestNames() ([]interestName, error) {
+	catalog, err := list.GetInterestCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []interestName
+	for _, category := range catalog.Categories {
+		for _, interest := range catalog.Interests[category.ID] {
+			names = append(names, interestName{id: interest.ID, name: InterestName(category.Title, interest.Name)})
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,101 @@
+package gochimp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeMemberExportServer(t *testing.T, query *string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/merge-fields", func(w http.ResponseWriter, r *http.Request) {
+		fields := &ListOfMergeFields{MergeFields: []MergeField{
+			{Tag: "AGE", Type: MERGE_FIELD_TYPE_NUMBER, DisplayOrder: 2},
+			{Tag: "FNAME", Type: MERGE_FIELD_TYPE_TEXT, DisplayOrder: 1},
+		}}
+		fields.TotalItems = 2
+		writeJSON(w, fields)
+	})
+	mux.HandleFunc("/lists/list1/interest-categories", func(w http.ResponseWriter, r *http.Request) {
+		categories := &ListOfInterestCategories{Categories: []InterestCategory{{ID: "cat1"}}}
+		categories.Categories[0].Title = "Topics"
+		writeJSON(w, categories)
+	})
+	mux.HandleFunc("/lists/list1/interest-categories/cat1/interests", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfInterests{Interests: []Interest{{ID: "i1", Name: "Go"}, {ID: "i2", Name: "Rust"}}})
+	})
+	mux.HandleFunc("/lists/list1/members", func(w http.ResponseWriter, r *http.Request) {
+		*query = r.URL.RawQuery
+		members := &ListOfMembers{Members: []Member{{ID: "m1"}, {ID: "m2"}}}
+		members.Members[0].EmailAddress = "ada@example.com"
+		members.Members[0].MergeFields = MergeFields{"FNAME": "Ada", "AGE": 36.0}
+		members.Members[0].Interests = map[string]bool{"i1": true}
+		members.Members[0].Tags = []MemberTag{{Name: "vip"}, {Name: "beta"}}
+		members.Members[1].EmailAddress = "bob@example.com"
+		members.TotalItems = 2
+		writeJSON(w, members)
+	})
+	return mux
+}
+
+func TestMemberExport(t *testing.T) {
+	var query string
+	api, server := testAPIServer(fakeMemberExportServer(t, &query))
+	defer server.Close()
+
+	exporter := api.NewListResponse("list1").NewMemberExporter(EXPORT_FORMAT_CSV)
+	exporter.Params = &MemberQueryParams{}
+	exporter.Params.Status = MEMBER_STATUS_SUBSCRIBED
+	exporter.Params.Fields = []string{"email_address", "merge_fields", "interests", "tags"}
+
+	buf := new(bytes.Buffer)
+	count, err := exporter.Export(buf)
+	fatalIf(t, err)
+	assert.Equal(t, 2, count)
+	assert.Contains(t, query, "status=subscribed")
+	assert.Contains(t, query, "fields=total_items%2Cmembers.email_address%2Cmembers.merge_fields")
+
+	rows, err := csv.NewReader(buf).ReadAll()
+	fatalIf(t, err)
+	assert.Equal(t, [][]string{
+		{"email_address", "merge_fields.FNAME", "merge_fields.AGE", "interests.Topics > Go", "interests.Topics > Rust", "tags"},
+		{"ada@example.com", "Ada", "36", "true", "false", "vip,beta"},
+		{"bob@example.com", "", "", "false", "false", ""},
+	}, rows)
+
+	exporter.Format = EXPORT_FORMAT_JSONL
+	buf.Reset()
+	_, err = exporter.Export(buf)
+	fatalIf(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		row := map[string]string{}
+		fatalIf(t, json.Unmarshal([]byte(lines[0]), &row))
+		assert.Equal(t, "Ada", row["merge_fields.FNAME"])
+		assert.Equal(t, "vip,beta", row["tags"])
+	}
+
+	exporter.Format = EXPORT_FORMAT_PARQUET
+	exporter.Params.Fields = nil
+	buf.Reset()
+	_, err = exporter.Export(buf)
+	fatalIf(t, err)
+	data := buf.Bytes()
+	assert.Equal(t, "PAR1", string(data[:4]))
+	assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := data[len(data)-8-footer : len(data)-8]
+	assert.Contains(t, string(meta), "stats_avg_open_rate")
+	assert.Contains(t, string(meta), "interests_Topics_Rust")
+	assert.Contains(t, string(data[:len(data)-8-footer]), "\x0f\x00\x00\x00ada@example.com")
+
+	exporter.Format = "xml"
+	_, err = exporter.Export(buf)
+	assert.Error(t, err)
+}
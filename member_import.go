@@ -0,0 +1,418 @@
+package gochimp3
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxBatchSubscribeSize          = 500
+	defaultMemberImportConcurrency = 4
+)
+
+// MemberImporter subscribes the rows of a CSV file to a list in batches of
+// up to 500 members through BatchSubscribeMembers.
+type MemberImporter struct {
+	List *ListResponse
+
+	// EmailColumn is the header of the column with the email addresses
+	EmailColumn string
+
+	// Columns maps CSV headers to the merge tags they are imported in,
+	// columns not in the map are ignored.
+	Columns map[string]string
+
+	// Status is the status of new members, one of MEMBER_STATUS_*
+	Status         string
+	UpdateExisting bool
+
+	BatchSize   int
+	Concurrency int
+
+	// Rejects receives the rejected rows as CSV, with the header of the
+	// input and an extra error column.
+	Rejects io.Writer
+}
+
+func (list *ListResponse) NewMemberImporter(columns map[string]string) *MemberImporter {
+	return &MemberImporter{
+		List:        list,
+		EmailColumn: "email",
+		Columns:     columns,
+		Status:      MEMBER_STATUS_SUBSCRIBED,
+		BatchSize:   maxBatchSubscribeSize,
+		Concurrency: defaultMemberImportConcurrency,
+	}
+}
+
+// MemberImportSummary counts what happened to the rows of an import
+type MemberImportSummary struct {
+	Rows     int `json:"rows"`
+	Created  int `json:"created"`
+	Updated  int `json:"updated"`
+	Rejected int `json:"rejected"`
+	Batches  int `json:"batches"`
+}
+
+func (summary *MemberImportSummary) String() string {
+	return fmt.Sprintf("%d rows: %d created, %d updated, %d rejected in %d batches",
+		summary.Rows, summary.Created, summary.Updated, summary.Rejected, summary.Batches)
+}
+
+// memberImportRow is a valid row waiting for its batch
+type memberImportRow struct {
+	record  []string
+	request MemberRequest
+}
+
+// memberImportColumn is a mapped column with the merge field it goes to
+type memberImportColumn struct {
+	index int
+	field MergeField
+}
+
+// Import reads the CSV from r, with a header line, validates every row
+// against the merge fields of the list and subscribes the valid ones.
+// Invalid rows and rows refused by Mailchimp are written to Rejects. The
+// file is streamed, only the email addresses already seen are kept.
+func (importer *MemberImporter) Import(r io.Reader) (*MemberImportSummary, error) {
+	if err := importer.List.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	// Rows with a different column count are rejected one by one, the
+	// import does not stop on them.
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	emailIndex, columns, err := importer.mapColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	rejects := &memberImportRejects{columns: len(header), emailIndex: emailIndex}
+	if importer.Rejects != nil {
+		rejects.w = csv.NewWriter(importer.Rejects)
+		if err := rejects.w.Write(append(append([]string{}, header...), "error")); err != nil {
+			return nil, err
+		}
+	}
+
+	batchSize := importer.BatchSize
+	if batchSize <= 0 || batchSize > maxBatchSubscribeSize {
+		batchSize = maxBatchSubscribeSize
+	}
+	concurrency := importer.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultMemberImportConcurrency
+	}
+
+	summary := &MemberImportSummary{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	batches := make(chan []memberImportRow)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				created, updated := importer.subscribe(batch, rejects)
+
+				mu.Lock()
+				summary.Created += created
+				summary.Updated += updated
+				mu.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[string]bool)
+	var batch []memberImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			close(batches)
+			wg.Wait()
+			return summary, err
+		}
+		summary.Rows++
+
+		if len(record) != len(header) {
+			rejects.reject(record, fmt.Sprintf("The row has %d columns instead of %d", len(record), len(header)))
+			continue
+		}
+
+		row, err := importer.parseRow(record, emailIndex, columns)
+		if err == nil {
+			email := strings.ToLower(row.request.EmailAddress)
+			if seen[email] {
+				err = errors.New("Duplicate email address in the file")
+			}
+			seen[email] = true
+		}
+		if err != nil {
+			rejects.reject(record, err.Error())
+			continue
+		}
+
+		batch = append(batch, *row)
+		if len(batch) == batchSize {
+			summary.Batches++
+			batches <- batch
+			batch = nil
+		}
+	}
+
+	if len(batch) > 0 {
+		summary.Batches++
+		batches <- batch
+	}
+	close(batches)
+	wg.Wait()
+
+	summary.Rejected = rejects.count
+	return summary, rejects.flush()
+}
+
+// mapColumns finds the email column and the merge field of each mapped column
+func (importer *MemberImporter) mapColumns(header []string) (int, []memberImportColumn, error) {
+	fields, err := importer.List.GetAllMergeFields()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	byTag := make(map[string]MergeField, len(fields))
+	for _, field := range fields {
+		byTag[field.Tag] = field
+	}
+
+	emailIndex := -1
+	mapped := make(map[string]bool)
+	var columns []memberImportColumn
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, importer.EmailColumn) {
+			emailIndex = i
+		}
+
+		tag, ok := importer.Columns[name]
+		if !ok {
+			continue
+		}
+		field, ok := byTag[tag]
+		if !ok {
+			return 0, nil, fmt.Errorf("The list has no merge field %s for column %s", tag, name)
+		}
+		columns = append(columns, memberImportColumn{index: i, field: field})
+		mapped[tag] = true
+	}
+
+	if emailIndex < 0 {
+		return 0, nil, fmt.Errorf("The CSV has no %s column", importer.EmailColumn)
+	}
+	for name := range importer.Columns {
+		if !containsHeader(header, name) {
+			return 0, nil, fmt.Errorf("The CSV has no %s column", name)
+		}
+	}
+	for _, field := range fields {
+		if field.Required && !mapped[field.Tag] {
+			return 0, nil, fmt.Errorf("The required merge field %s is not mapped to a column", field.Tag)
+		}
+	}
+
+	return emailIndex, columns, nil
+}
+
+func containsHeader(header []string, name string) bool {
+	for _, h := range header {
+		if strings.TrimSpace(h) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRow validates a row and builds the member it subscribes
+func (importer *MemberImporter) parseRow(record []string, emailIndex int, columns []memberImportColumn) (*memberImportRow, error) {
+	email, err := validateEmailAddress(record[emailIndex])
+	if err != nil {
+		return nil, err
+	}
+
+	row := &memberImportRow{record: record}
+	row.request.EmailAddress = email
+	row.request.Status = importer.Status
+
+	for _, column := range columns {
+		value := strings.TrimSpace(record[column.index])
+		if value == "" {
+			if column.field.Required {
+				return nil, fmt.Errorf("%s is required", column.field.Tag)
+			}
+			continue
+		}
+
+		parsed, err := parseMergeFieldValue(&column.field, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", column.field.Tag, err)
+		}
+		row.request.MergeFields.set(column.field.Tag, parsed)
+	}
+
+	return row, nil
+}
+
+// validateEmailAddress checks s is a bare email address and returns it trimmed
+func validateEmailAddress(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	address, err := mail.ParseAddress(s)
+	if err != nil || address.Address != s || !strings.Contains(s[strings.LastIndex(s, "@"):], ".") {
+		return "", fmt.Errorf("Invalid email address %q", s)
+	}
+	return s, nil
+}
+
+// parseMergeFieldValue checks value is valid for the type of field and
+// returns it as it is sent to Mailchimp.
+func parseMergeFieldValue(field *MergeField, value string) (interface{}, error) {
+	switch field.Type {
+	case MERGE_FIELD_TYPE_NUMBER:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return n, nil
+	case MERGE_FIELD_TYPE_DATE, MERGE_FIELD_TYPE_BIRTHDAY:
+		if _, err := time.Parse(mergeFieldDateLayout(field.Options.DateFormat), value); err != nil {
+			return nil, fmt.Errorf("%q is not a date in the format %s", value, field.Options.DateFormat)
+		}
+	case MERGE_FIELD_TYPE_RADIO, MERGE_FIELD_TYPE_DROPDOWN:
+		if !containsString(field.Options.Choices, value) {
+			return nil, fmt.Errorf("%q is not one of the choices %s", value, strings.Join(field.Options.Choices, ", "))
+		}
+	case MERGE_FIELD_TYPE_URL, MERGE_FIELD_TYPE_IMAGEURL:
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%q is not a URL", value)
+		}
+	case MERGE_FIELD_TYPE_PHONE:
+		if field.Options.PhoneFormat == PHONE_FORMAT_US && countDigits(value) != 10 {
+			return nil, fmt.Errorf("%q is not a US phone number", value)
+		}
+	case MERGE_FIELD_TYPE_ZIP:
+		if _, err := strconv.Atoi(value); err != nil || len(value) != 5 {
+			return nil, fmt.Errorf("%q is not a zip code", value)
+		}
+	}
+	return value, nil
+}
+
+func containsString(s []string, value string) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// subscribe sends a batch and rejects the rows Mailchimp refused
+func (importer *MemberImporter) subscribe(batch []memberImportRow, rejects *memberImportRejects) (int, int) {
+	body := &BatchSubscribeMembersRequest{
+		Members:        make([]MemberRequest, len(batch)),
+		UpdateExisting: importer.UpdateExisting,
+	}
+	rows := make(map[string][]string, len(batch))
+	for i, row := range batch {
+		body.Members[i] = row.request
+		rows[strings.ToLower(row.request.EmailAddress)] = row.record
+	}
+
+	response, err := importer.List.BatchSubscribeMembers(body)
+	if err != nil {
+		for _, row := range batch {
+			rejects.reject(row.record, err.Error())
+		}
+		return 0, 0
+	}
+
+	for _, batchErr := range response.ErrorMessages {
+		record, ok := rows[strings.ToLower(strings.TrimSpace(batchErr.EmailAddress))]
+		if !ok {
+			rejects.rejectEmail(batchErr.EmailAddress, batchErr.ErrorMessage)
+			continue
+		}
+		rejects.reject(record, batchErr.ErrorMessage)
+	}
+
+	return response.TotalCreated, response.TotalUpdated
+}
+
+// memberImportRejects writes rejected rows, from any goroutine. Every row
+// has the columns of the header followed by the reason.
+type memberImportRejects struct {
+	mu         sync.Mutex
+	w          *csv.Writer
+	columns    int
+	emailIndex int
+	count      int
+	err        error
+}
+
+func (rejects *memberImportRejects) reject(record []string, reason string) {
+	rejects.mu.Lock()
+	defer rejects.mu.Unlock()
+
+	rejects.count++
+	if rejects.w != nil && rejects.err == nil {
+		row := make([]string, rejects.columns+1)
+		copy(row[:rejects.columns], record)
+		row[rejects.columns] = reason
+		rejects.err = rejects.w.Write(row)
+	}
+}
+
+// rejectEmail rejects a member Mailchimp refused that matches no row
+func (rejects *memberImportRejects) rejectEmail(email string, reason string) {
+	record := make([]string, rejects.columns)
+	record[rejects.emailIndex] = email
+	rejects.reject(record, reason)
+}
+
+func (rejects *memberImportRejects) flush() error {
+	if rejects.w == nil {
+		return nil
+	}
+	rejects.w.Flush()
+	if rejects.err != nil {
+		return rejects.err
+	}
+	return rejects.w.Error()
+}
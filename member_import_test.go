@@ -0,0 +1,156 @@
+package gochimp3
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemberImport(t *testing.T) {
+	var batches []BatchSubscribeMembersRequest
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/merge-fields", func(w http.ResponseWriter, r *http.Request) {
+		fields := &ListOfMergeFields{MergeFields: []MergeField{
+			{Tag: "FNAME", Type: MERGE_FIELD_TYPE_TEXT, Required: true},
+			{Tag: "AGE", Type: MERGE_FIELD_TYPE_NUMBER},
+			{Tag: "BDAY", Type: MERGE_FIELD_TYPE_BIRTHDAY, Options: MergeFieldOptions{DateFormat: BIRTHDAY_FORMAT_MONTH_FIRST}},
+			{Tag: "PLAN", Type: MERGE_FIELD_TYPE_DROPDOWN, Options: MergeFieldOptions{Choices: []string{"free", "pro"}}},
+		}}
+		fields.TotalItems = 4
+		writeJSON(w, fields)
+	})
+	mux.HandleFunc("/lists/list1", func(w http.ResponseWriter, r *http.Request) {
+		body := BatchSubscribeMembersRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		batches = append(batches, body)
+
+		response := &BatchSubscribeMembersResponse{TotalCreated: len(body.Members)}
+		for _, member := range body.Members {
+			if member.EmailAddress == "Fake@Example.com" {
+				response.TotalCreated--
+				response.ErrorMessages = append(response.ErrorMessages, BatchSubscribeMembersError{
+					EmailAddress: "fake@example.com",
+					ErrorMessage: "looks fake or invalid",
+				})
+			}
+		}
+		writeJSON(w, response)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	input := "Email,First name,Age,Birthday,Plan,Notes\n" +
+		"ada@example.com,Ada,36,12/10,pro,first\n" +
+		"bob@example.com,Bob,old,01/01,free,\n" +
+		"not an email,Cy,,,,\n" +
+		"Fake@Example.com,Dan,,,free,\n" +
+		"ADA@example.com,Ada,,,,duplicate\n" +
+		"eve@example.com,,,,,\n" +
+		"fay@example.com,Fay,,13/40,,\n" +
+		"gus@example.com,Gus,,,gold,\n"
+
+	rejects := new(bytes.Buffer)
+	importer := api.NewListResponse("list1").NewMemberImporter(map[string]string{
+		"First name": "FNAME",
+		"Age":        "AGE",
+		"Birthday":   "BDAY",
+		"Plan":       "PLAN",
+	})
+	importer.Rejects = rejects
+	importer.BatchSize = 1
+
+	summary, err := importer.Import(strings.NewReader(input))
+	fatalIf(t, err)
+	assert.Equal(t, "8 rows: 1 created, 0 updated, 7 rejected in 2 batches", summary.String())
+
+	if assert.Len(t, batches, 2) {
+		ada := batches[0].Members[0]
+		if ada.EmailAddress != "ada@example.com" {
+			ada = batches[1].Members[0]
+		}
+		assert.Equal(t, MergeFields{"FNAME": "Ada", "AGE": 36.0, "BDAY": "12/10", "PLAN": "pro"}, ada.MergeFields)
+		assert.Equal(t, MEMBER_STATUS_SUBSCRIBED, ada.Status)
+	}
+
+	rows, err := csv.NewReader(rejects).ReadAll()
+	fatalIf(t, err)
+	assert.Equal(t, []string{"Email", "First name", "Age", "Birthday", "Plan", "Notes", "error"}, rows[0])
+	reasons := map[string]string{}
+	for _, row := range rows[1:] {
+		reasons[row[0]] = row[6]
+	}
+	assert.Equal(t, map[string]string{
+		"bob@example.com":  `AGE: "old" is not a number`,
+		"not an email":     `Invalid email address "not an email"`,
+		"Fake@Example.com": "looks fake or invalid",
+		"ADA@example.com":  "Duplicate email address in the file",
+		"eve@example.com":  "FNAME is required",
+		"fay@example.com":  `BDAY: "13/40" is not a date in the format MM/DD`,
+		"gus@example.com":  `PLAN: "gold" is not one of the choices free, pro`,
+	}, reasons)
+
+	importer.Columns["Phone"] = "PHONE"
+	_, err = importer.Import(strings.NewReader(input))
+	assert.Error(t, err)
+}
+
+func TestMemberImportRaggedRows(t *testing.T) {
+	var subscribed []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/merge-fields", func(w http.ResponseWriter, r *http.Request) {
+		fields := &ListOfMergeFields{MergeFields: []MergeField{{Tag: "FNAME", Type: MERGE_FIELD_TYPE_TEXT}}}
+		fields.TotalItems = 1
+		writeJSON(w, fields)
+	})
+	mux.HandleFunc("/lists/list1", func(w http.ResponseWriter, r *http.Request) {
+		body := BatchSubscribeMembersRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		for _, member := range body.Members {
+			subscribed = append(subscribed, member.EmailAddress)
+		}
+
+		response := &BatchSubscribeMembersResponse{TotalCreated: len(body.Members)}
+		if body.Members[0].EmailAddress == "cy@example.com" {
+			response.ErrorMessages = append(response.ErrorMessages, BatchSubscribeMembersError{
+				EmailAddress: "alias@example.com",
+				ErrorMessage: "merged with another member",
+			})
+		}
+		writeJSON(w, response)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	input := "email,First name,Notes\n" +
+		"ada@example.com,Ada,first\n" +
+		"bob@example.com\n" +
+		"cy@example.com,Cy,\n"
+
+	rejects := new(bytes.Buffer)
+	importer := api.NewListResponse("list1").NewMemberImporter(map[string]string{"First name": "FNAME"})
+	importer.Rejects = rejects
+	importer.BatchSize = 1
+	importer.Concurrency = 1
+
+	summary, err := importer.Import(strings.NewReader(input))
+	fatalIf(t, err)
+	assert.Equal(t, "3 rows: 2 created, 0 updated, 2 rejected in 2 batches", summary.String())
+	assert.Equal(t, []string{"ada@example.com", "cy@example.com"}, subscribed)
+
+	rows, err := csv.NewReader(rejects).ReadAll()
+	fatalIf(t, err)
+	assert.Equal(t, [][]string{
+		{"email", "First name", "Notes", "error"},
+		{"bob@example.com", "", "", "The row has 1 columns instead of 3"},
+		{"alias@example.com", "", "", "merged with another member"},
+	}, rows)
+}
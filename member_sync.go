@@ -0,0 +1,385 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type MemberChangeType string
+
+const (
+	MEMBER_CHANGE_CREATED      MemberChangeType = "created"
+	MEMBER_CHANGE_UPDATED      MemberChangeType = "updated"
+	MEMBER_CHANGE_UNSUBSCRIBED MemberChangeType = "unsubscribed"
+	MEMBER_CHANGE_DELETED      MemberChangeType = "deleted" // archived or cleaned
+
+	defaultSyncPageSize = 500
+)
+
+// MemberChange is emitted by a MemberSyncer for every member that changed
+type MemberChange struct {
+	Type   MemberChangeType
+	Member *Member
+}
+
+// SyncCursor tracks how far a sync got through the members with one status.
+// Members are read in ascending order of last change, LastChanged is the time
+// of the last member emitted and SeenIDs the members emitted at that time.
+type SyncCursor struct {
+	LastChanged time.Time `json:"last_changed"`
+	SeenIDs     []string  `json:"seen_ids,omitempty"`
+	Offset      int       `json:"offset,omitempty"`
+
+	// seenSet indexes SeenIDs, which can be long when many members changed
+	// in the same second, e.g. after a bulk import
+	seenSet map[string]bool
+}
+
+func (cursor *SyncCursor) seen(id string) bool {
+	if cursor.seenSet == nil {
+		cursor.seenSet = make(map[string]bool, len(cursor.SeenIDs))
+		for _, seen := range cursor.SeenIDs {
+			cursor.seenSet[seen] = true
+		}
+	}
+	return cursor.seenSet[id]
+}
+
+// advance records a member emitted, only the members changed at the latest
+// time are kept
+func (cursor *SyncCursor) advance(lastChanged time.Time, id string) {
+	if lastChanged.After(cursor.LastChanged) {
+		cursor.LastChanged = lastChanged
+		cursor.SeenIDs = nil
+		cursor.seenSet = nil
+		cursor.Offset = 0
+	}
+	if !cursor.seen(id) {
+		cursor.SeenIDs = append(cursor.SeenIDs, id)
+		cursor.seenSet[id] = true
+	}
+}
+
+// SyncCheckpoint is the persisted progress of the sync of a list, with a
+// cursor for every status filter the sync goes through.
+type SyncCheckpoint struct {
+	ListID    string                 `json:"list_id"`
+	Cursors   map[string]*SyncCursor `json:"cursors"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+func (cp *SyncCheckpoint) cursor(status string) *SyncCursor {
+	if cp.Cursors == nil {
+		cp.Cursors = make(map[string]*SyncCursor)
+	}
+	cursor, ok := cp.Cursors[status]
+	if !ok {
+		cursor = new(SyncCursor)
+		cp.Cursors[status] = cursor
+	}
+	return cursor
+}
+
+// CheckpointStore persists the progress of member syncs. Load returns nil
+// without an error when there is no checkpoint for the list yet.
+type CheckpointStore interface {
+	Load(listID string) (*SyncCheckpoint, error)
+	Save(checkpoint *SyncCheckpoint) error
+}
+
+// MemoryCheckpointStore keeps checkpoints in memory, mostly for tests
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string][]byte
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string][]byte)}
+}
+
+func (store *MemoryCheckpointStore) Load(listID string) (*SyncCheckpoint, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	data, ok := store.checkpoints[listID]
+	if !ok {
+		return nil, nil
+	}
+
+	checkpoint := new(SyncCheckpoint)
+	return checkpoint, json.Unmarshal(data, checkpoint)
+}
+
+func (store *MemoryCheckpointStore) Save(checkpoint *SyncCheckpoint) error {
+	// stored serialized so later changes by the syncer don't leak in
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.checkpoints[checkpoint.ListID] = data
+	return nil
+}
+
+// FileCheckpointStore keeps the checkpoints of all lists in a JSON file,
+// which is replaced atomically on every save.
+type FileCheckpointStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{Path: path}
+}
+
+func (store *FileCheckpointStore) Load(listID string) (*SyncCheckpoint, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	checkpoints, err := store.read()
+	if err != nil {
+		return nil, err
+	}
+	return checkpoints[listID], nil
+}
+
+func (store *FileCheckpointStore) Save(checkpoint *SyncCheckpoint) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	checkpoints, err := store.read()
+	if err != nil {
+		return err
+	}
+	checkpoints[checkpoint.ListID] = checkpoint
+
+	return writeJSONFile(store.Path, checkpoints)
+}
+
+func (store *FileCheckpointStore) read() (map[string]*SyncCheckpoint, error) {
+	checkpoints := make(map[string]*SyncCheckpoint)
+	err := readJSONFile(store.Path, &checkpoints)
+	if os.IsNotExist(err) {
+		return checkpoints, nil
+	}
+	return checkpoints, err
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSONFile writes to a temporary file renamed over path, so a crash never
+// leaves a truncated file behind.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// MemberSyncResult counts the changes emitted by a run of a MemberSyncer
+type MemberSyncResult struct {
+	Created      int
+	Updated      int
+	Unsubscribed int
+	Deleted      int
+}
+
+// MemberSyncer emits the members of a list which changed since its last run.
+//
+// Progress is saved to Store after every page and when OnChange fails, so a
+// run resumes where the previous one stopped. Changes handled after the last
+// save are emitted again after a crash, OnChange must be idempotent.
+type MemberSyncer struct {
+	List     *ListResponse
+	Store    CheckpointStore
+	OnChange func(MemberChange) error
+
+	// PageSize is the number of members requested at once.
+	PageSize int
+
+	// Statuses are the status filters the sync goes through. The default ""
+	// returns all members but archived ones, which need their own pass.
+	Statuses []string
+}
+
+func (list *ListResponse) NewMemberSyncer(store CheckpointStore, onChange func(MemberChange) error) *MemberSyncer {
+	return &MemberSyncer{
+		List:     list,
+		Store:    store,
+		OnChange: onChange,
+		PageSize: defaultSyncPageSize,
+		Statuses: []string{"", MEMBER_STATUS_ARCHIVED},
+	}
+}
+
+// Run emits every change since the last checkpoint
+func (syncer *MemberSyncer) Run() (*MemberSyncResult, error) {
+	if err := syncer.List.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+	if syncer.Store == nil || syncer.OnChange == nil {
+		return nil, errors.New("A member sync needs a Store and OnChange")
+	}
+
+	checkpoint, err := syncer.Store.Load(syncer.List.ID)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		checkpoint = &SyncCheckpoint{ListID: syncer.List.ID}
+	}
+
+	result := new(MemberSyncResult)
+	for _, status := range syncer.Statuses {
+		if err := syncer.syncStatus(checkpoint, status, result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func (syncer *MemberSyncer) syncStatus(checkpoint *SyncCheckpoint, status string, result *MemberSyncResult) error {
+	cursor := checkpoint.cursor(status)
+	since := cursor.LastChanged
+
+	pageSize := syncer.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSyncPageSize
+	}
+
+	for {
+		params := &MemberQueryParams{}
+		params.Status = status
+		params.SortField = MEMBER_SORT_LAST_CHANGED
+		params.SortDirection = "ASC"
+		params.Count = pageSize
+		params.Offset = cursor.Offset
+		if !cursor.LastChanged.IsZero() {
+			// since_last_changed is exclusive and has a one second precision,
+			// back off so members changed in the same second aren't missed
+			params.SinceLastChanged = cursor.LastChanged.Add(-time.Second)
+		}
+
+		page, err := syncer.List.GetMembers(params)
+		if err != nil {
+			return err
+		}
+
+		emitted := 0
+		for i := range page.Members {
+			member := &page.Members[i]
+			lastChanged, err := time.Parse(time.RFC3339, member.LastChanged)
+			if err != nil {
+				return err
+			}
+
+			if lastChanged.Before(cursor.LastChanged) ||
+				(lastChanged.Equal(cursor.LastChanged) && cursor.seen(member.ID)) {
+				continue
+			}
+
+			change := MemberChange{Type: classifyMemberChange(member, since), Member: member}
+			if err := syncer.OnChange(change); err != nil {
+				return syncer.save(checkpoint, err)
+			}
+			result.count(change.Type)
+			emitted++
+
+			cursor.advance(lastChanged, member.ID)
+		}
+
+		if len(page.Members) < pageSize {
+			cursor.Offset = 0
+			return syncer.save(checkpoint, nil)
+		}
+
+		// a full page of members changed in the same already seen second
+		if emitted == 0 {
+			cursor.Offset += len(page.Members)
+		}
+
+		if err := syncer.save(checkpoint, nil); err != nil {
+			return err
+		}
+	}
+}
+
+func (syncer *MemberSyncer) save(checkpoint *SyncCheckpoint, cause error) error {
+	checkpoint.UpdatedAt = time.Now()
+	if err := syncer.Store.Save(checkpoint); err != nil && cause == nil {
+		return err
+	}
+	return cause
+}
+
+func (result *MemberSyncResult) count(change MemberChangeType) {
+	switch change {
+	case MEMBER_CHANGE_CREATED:
+		result.Created++
+	case MEMBER_CHANGE_UPDATED:
+		result.Updated++
+	case MEMBER_CHANGE_UNSUBSCRIBED:
+		result.Unsubscribed++
+	case MEMBER_CHANGE_DELETED:
+		result.Deleted++
+	}
+}
+
+// classifyMemberChange treats members who signed up or opted in after since
+// as created, since is zero on the first sync of a list.
+func classifyMemberChange(member *Member, since time.Time) MemberChangeType {
+	switch member.Status {
+	case MEMBER_STATUS_ARCHIVED, MEMBER_STATUS_CLEANED:
+		return MEMBER_CHANGE_DELETED
+	case MEMBER_STATUS_UNSUBSCRIBED:
+		return MEMBER_CHANGE_UNSUBSCRIBED
+	}
+
+	if since.IsZero() {
+		return MEMBER_CHANGE_CREATED
+	}
+
+	for _, timestamp := range []string{member.TimestampSignup, member.TimestampOpt} {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err == nil && !t.Before(since) {
+			return MEMBER_CHANGE_CREATED
+		}
+	}
+
+	return MEMBER_CHANGE_UPDATED
+}
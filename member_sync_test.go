@@ -0,0 +1,151 @@
+package gochimp3
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMembers serves /lists/{id}/members honoring the filters used by the syncer
+type fakeMembers struct {
+	mu      sync.Mutex
+	members []Member
+}
+
+func (fake *fakeMembers) set(id, status string, changed time.Time) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	member := Member{ID: id, ListID: "list1", LastChanged: changed.Format(time.RFC3339)}
+	member.Status = status
+	member.TimestampSignup = changed.Format(time.RFC3339)
+	for i := range fake.members {
+		if fake.members[i].ID == id {
+			member.TimestampSignup = fake.members[i].TimestampSignup
+			fake.members[i] = member
+			return
+		}
+	}
+	fake.members = append(fake.members, member)
+}
+
+func (fake *fakeMembers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	q := r.URL.Query()
+	var since time.Time
+	if s := q.Get("since_last_changed"); s != "" {
+		since, _ = time.Parse(time.RFC3339, s)
+	}
+
+	matching := []Member{}
+	for _, m := range fake.members {
+		changed, _ := time.Parse(time.RFC3339, m.LastChanged)
+		archived := m.Status == MEMBER_STATUS_ARCHIVED
+		if archived != (q.Get("status") == MEMBER_STATUS_ARCHIVED) || !changed.After(since) {
+			continue
+		}
+		matching = append(matching, m)
+	}
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].LastChanged < matching[j].LastChanged })
+
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	count, _ := strconv.Atoi(q.Get("count"))
+	page := &ListOfMembers{ListID: "list1"}
+	page.TotalItems = len(matching)
+	if offset < len(matching) {
+		matching = matching[offset:]
+		if len(matching) > count {
+			matching = matching[:count]
+		}
+		page.Members = matching
+	}
+	writeJSON(w, page)
+}
+
+func TestMemberSyncer(t *testing.T) {
+	fake := new(fakeMembers)
+	api, server := testAPIServer(fake)
+	defer server.Close()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		fake.set("m"+strconv.Itoa(i), MEMBER_STATUS_SUBSCRIBED, start)
+	}
+	fake.set("m5", MEMBER_STATUS_SUBSCRIBED, start.Add(time.Minute))
+
+	dir, err := ioutil.TempDir("", "gochimp3")
+	fatalIf(t, err)
+	defer os.RemoveAll(dir)
+	store := NewFileCheckpointStore(filepath.Join(dir, "checkpoints.json"))
+
+	var changes []MemberChange
+	failAt := 3
+	syncer := api.NewListResponse("list1").NewMemberSyncer(store, func(change MemberChange) error {
+		if len(changes) == failAt {
+			return errors.New("crash")
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	syncer.PageSize = 2
+
+	// crash in the middle of a page, then resume
+	_, err = syncer.Run()
+	assert.EqualError(t, err, "crash")
+	assert.Len(t, changes, 3)
+
+	failAt = -1
+	result, err := syncer.Run()
+	fatalIf(t, err)
+	assert.Equal(t, 3, result.Created)
+	assert.Len(t, changes, 6)
+	seen := map[string]bool{}
+	for _, change := range changes {
+		assert.Equal(t, MEMBER_CHANGE_CREATED, change.Type)
+		assert.False(t, seen[change.Member.ID], "%s emitted twice", change.Member.ID)
+		seen[change.Member.ID] = true
+	}
+
+	// nothing changed
+	result, err = syncer.Run()
+	fatalIf(t, err)
+	assert.Equal(t, MemberSyncResult{}, *result)
+
+	later := start.Add(time.Hour)
+	fake.set("m1", MEMBER_STATUS_UNSUBSCRIBED, later)
+	fake.set("m2", MEMBER_STATUS_SUBSCRIBED, later)
+	fake.set("m3", MEMBER_STATUS_ARCHIVED, later)
+	fake.set("m6", MEMBER_STATUS_SUBSCRIBED, later)
+
+	changes = nil
+	result, err = syncer.Run()
+	fatalIf(t, err)
+	assert.Equal(t, MemberSyncResult{Created: 1, Updated: 1, Unsubscribed: 1, Deleted: 1}, *result)
+
+	byID := map[string]MemberChangeType{}
+	for _, change := range changes {
+		byID[change.Member.ID] = change.Type
+	}
+	assert.Equal(t, map[string]MemberChangeType{
+		"m1": MEMBER_CHANGE_UNSUBSCRIBED,
+		"m2": MEMBER_CHANGE_UPDATED,
+		"m3": MEMBER_CHANGE_DELETED,
+		"m6": MEMBER_CHANGE_CREATED,
+	}, byID)
+
+	checkpoint, err := store.Load("list1")
+	fatalIf(t, err)
+	assert.True(t, later.Equal(checkpoint.Cursors[""].LastChanged))
+	assert.ElementsMatch(t, []string{"m1", "m2", "m6"}, checkpoint.Cursors[""].SeenIDs)
+}
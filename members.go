@@ -5,14 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 )
 
 const (
 	members_path       = "/lists/%s/members"
 	single_member_path = members_path + "/%s"
 
-	member_activity_path = single_member_path + "/activity"
-	member_goals_path    = single_member_path + "/goals"
+	member_activity_path      = single_member_path + "/activity"
+	member_activity_feed_path = single_member_path + "/activity-feed"
+	member_goals_path         = single_member_path + "/goals"
 
 	member_notes_path       = single_member_path + "/notes"
 	single_member_note_path = member_notes_path + "/%s"
@@ -21,8 +24,66 @@ const (
 	single_member_tag_path = member_tags_path + "/%s"
 
 	delete_permanent_path = single_member_path + "/actions/delete-permanent"
+
+	MEMBER_STATUS_SUBSCRIBED    = "subscribed"
+	MEMBER_STATUS_UNSUBSCRIBED  = "unsubscribed"
+	MEMBER_STATUS_CLEANED       = "cleaned"
+	MEMBER_STATUS_PENDING       = "pending"
+	MEMBER_STATUS_TRANSACTIONAL = "transactional"
+	MEMBER_STATUS_ARCHIVED      = "archived"
+
+	MEMBER_SORT_TIMESTAMP_OPT    = "timestamp_opt"
+	MEMBER_SORT_TIMESTAMP_SIGNUP = "timestamp_signup"
+	MEMBER_SORT_LAST_CHANGED     = "last_changed"
+
+	INTEREST_MATCH_ANY  = "any"
+	INTEREST_MATCH_ALL  = "all"
+	INTEREST_MATCH_NONE = "none"
+
+	MEMBER_TAG_ACTIVE   = "active"
+	MEMBER_TAG_INACTIVE = "inactive"
 )
 
+// MemberQueryParams filters the members of a list. Status and SortField are
+// inherited from BasicQueryParams, see MEMBER_STATUS_* and MEMBER_SORT_*.
+type MemberQueryParams struct {
+	ExtendedQueryParams
+
+	EmailType          string
+	SinceTimestampOpt  time.Time
+	BeforeTimestampOpt time.Time
+	SinceLastChanged   time.Time
+	BeforeLastChanged  time.Time
+	UniqueEmailID      string
+	VIPOnly            bool
+	InterestCategoryID string
+	InterestIDs        []string
+	InterestMatch      string // one of INTEREST_MATCH_*
+	SinceLastCampaign  bool
+	UnsubscribedSince  time.Time
+}
+
+func (q *MemberQueryParams) Params() map[string]string {
+	m := q.ExtendedQueryParams.Params()
+	m["email_type"] = q.EmailType
+	m["since_timestamp_opt"] = formatQueryTime(q.SinceTimestampOpt)
+	m["before_timestamp_opt"] = formatQueryTime(q.BeforeTimestampOpt)
+	m["since_last_changed"] = formatQueryTime(q.SinceLastChanged)
+	m["before_last_changed"] = formatQueryTime(q.BeforeLastChanged)
+	m["unique_email_id"] = q.UniqueEmailID
+	m["interest_category_id"] = q.InterestCategoryID
+	m["interest_ids"] = strings.Join(q.InterestIDs, ",")
+	m["interest_match"] = q.InterestMatch
+	m["unsubscribed_since"] = formatQueryTime(q.UnsubscribedSince)
+	if q.VIPOnly {
+		m["vip_only"] = "true"
+	}
+	if q.SinceLastCampaign {
+		m["since_last_campaign"] = "true"
+	}
+	return m
+}
+
 type ListOfMembers struct {
 	baseList
 
@@ -31,38 +92,40 @@ type ListOfMembers struct {
 }
 
 type MemberResponse struct {
-	EmailAddress    string                 `json:"email_address"`
-	EmailType       string                 `json:"email_type,omitempty"`
-	Status          string                 `json:"status"`
-	StatusIfNew     string                 `json:"status_if_new,omitempty"`
-	MergeFields     map[string]interface{} `json:"merge_fields,omitempty"`
-	Interests       map[string]bool        `json:"interests,omitempty"`
-	Language        string                 `json:"language"`
-	VIP             bool                   `json:"vip"`
-	Location        *MemberLocation        `json:"location,omitempty"`
-	IPOpt           string                 `json:"ip_opt,omitempty"`
-	IPSignup        string                 `json:"ip_signup,omitempty"`
-	Tags            []MemberTag            `json:"tags,omitempty"`
-	TimestampSignup string                 `json:"timestamp_signup,omitempty"`
-	TimestampOpt    string                 `json:"timestamp_opt,omitempty"`
+	EmailAddress string          `json:"email_address"`
+	EmailType    string          `json:"email_type,omitempty"`
+	Status       string          `json:"status"`
+	StatusIfNew  string          `json:"status_if_new,omitempty"`
+	MergeFields  MergeFields     `json:"merge_fields,omitempty"`
+	Interests    map[string]bool `json:"interests,omitempty"`
+	Language     string          `json:"language"`
+	VIP          bool            `json:"vip"`
+	Location     *MemberLocation `json:"location,omitempty"`
+	IPOpt        string          `json:"ip_opt,omitempty"`
+	IPSignup     string          `json:"ip_signup,omitempty"`
+	Tags         []MemberTag     `json:"tags,omitempty"`
+
+	MarketingPermissions MarketingPermissions `json:"marketing_permissions,omitempty"`
+	TimestampSignup      string               `json:"timestamp_signup,omitempty"`
+	TimestampOpt         string               `json:"timestamp_opt,omitempty"`
 }
 
 type MemberRequest struct {
-	EmailAddress         string                 `json:"email_address"`
-	EmailType            string                 `json:"email_type,omitempty"`
-	Status               string                 `json:"status"`
-	StatusIfNew          string                 `json:"status_if_new,omitempty"`
-	MergeFields          map[string]interface{} `json:"merge_fields,omitempty"`
-	Interests            map[string]bool        `json:"interests,omitempty"`
-	Language             string                 `json:"language"`
-	VIP                  bool                   `json:"vip"`
-	Location             *MemberLocation        `json:"location,omitempty"`
-	MarketingPermissions *MarketingPermissions  `json:"marketing_permissions,omitempty"`
-	IPOpt                string                 `json:"ip_opt,omitempty"`
-	IPSignup             string                 `json:"ip_signup,omitempty"`
-	Tags                 []string               `json:"tags,omitempty"`
-	TimestampSignup      string                 `json:"timestamp_signup,omitempty"`
-	TimestampOpt         string                 `json:"timestamp_opt,omitempty"`
+	EmailAddress         string                `json:"email_address"`
+	EmailType            string                `json:"email_type,omitempty"`
+	Status               string                `json:"status"`
+	StatusIfNew          string                `json:"status_if_new,omitempty"`
+	MergeFields          MergeFields           `json:"merge_fields,omitempty"`
+	Interests            map[string]bool       `json:"interests,omitempty"`
+	Language             string                `json:"language"`
+	VIP                  bool                  `json:"vip"`
+	Location             *MemberLocation       `json:"location,omitempty"`
+	MarketingPermissions *MarketingPermissions `json:"marketing_permissions,omitempty"`
+	IPOpt                string                `json:"ip_opt,omitempty"`
+	IPSignup             string                `json:"ip_signup,omitempty"`
+	Tags                 []string              `json:"tags,omitempty"`
+	TimestampSignup      string                `json:"timestamp_signup,omitempty"`
+	TimestampOpt         string                `json:"timestamp_opt,omitempty"`
 }
 
 type Member struct {
@@ -99,13 +162,19 @@ func (mem *Member) WithApi(api *API) *Member {
 }
 
 func (mem *Member) SetIdByMail(email string) *Member {
+	mem.ID = SubscriberHash(email)
+	return mem
+}
+
+// SubscriberHash returns the ID Mailchimp uses for a member of a list: the MD5
+// hash of the lowercase email address, ignoring surrounding whitespace.
+func SubscriberHash(email string) string {
 	h := md5.New()
-	_, err := io.WriteString(h, email)
+	_, err := io.WriteString(h, strings.ToLower(strings.TrimSpace(email)))
 	if err != nil {
 		panic(err)
 	}
-	mem.ID = fmt.Sprintf("%x", h.Sum(nil))
-	return mem
+	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
 // The Member struct returned by this should be all you need to start calling APIs for this member,
@@ -148,7 +217,7 @@ type MemberTag struct {
 	Name string `json:"name"`
 }
 
-func (list *ListResponse) GetMembers(params *InterestCategoriesQueryParams) (*ListOfMembers, error) {
+func (list *ListResponse) GetMembers(params *MemberQueryParams) (*ListOfMembers, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return nil, err
 	}
@@ -161,13 +230,36 @@ func (list *ListResponse) GetMembers(params *InterestCategoriesQueryParams) (*Li
 		return nil, err
 	}
 
-	for _, m := range response.Members {
-		m.api = list.api
+	for i := range response.Members {
+		response.Members[i].api = list.api
 	}
 
 	return response, nil
 }
 
+// EachMember pages through the members of the list matching params, which may
+// be nil, and calls fn with each of them until it returns an error.
+func (list *ListResponse) EachMember(params *MemberQueryParams, fn func(*Member) error) error {
+	query := MemberQueryParams{}
+	if params != nil {
+		query = *params
+	}
+
+	return pageThrough(&query.ExtendedQueryParams, func() (int, int, error) {
+		page, err := list.GetMembers(&query)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for i := range page.Members {
+			if err := fn(&page.Members[i]); err != nil {
+				return 0, 0, err
+			}
+		}
+		return len(page.Members), page.TotalItems, nil
+	})
+}
+
 func (list *ListResponse) GetMember(id string, params *BasicQueryParams) (*Member, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return nil, err
@@ -216,6 +308,36 @@ func (list *ListResponse) AddOrUpdateMember(id string, body *MemberRequest) (*Me
 	return response, list.api.Request("PUT", endpoint, nil, body, response)
 }
 
+// unsubscribeMember only changes the status of the member, UpdateMember
+// would also send the empty fields of a MemberRequest.
+func (list *ListResponse) unsubscribeMember(id string) error {
+	if err := list.CanMakeRequest(); err != nil {
+		return err
+	}
+
+	body := struct {
+		Status string `json:"status"`
+	}{MEMBER_STATUS_UNSUBSCRIBED}
+
+	endpoint := fmt.Sprintf(single_member_path, list.ID, id)
+	return list.api.Request("PATCH", endpoint, nil, &body, nil)
+}
+
+// updateMemberMergeFields only changes the given merge fields of the member,
+// leaving its status and other fields alone.
+func (list *ListResponse) updateMemberMergeFields(id string, fields MergeFields) error {
+	if err := list.CanMakeRequest(); err != nil {
+		return err
+	}
+
+	body := struct {
+		MergeFields MergeFields `json:"merge_fields"`
+	}{fields}
+
+	endpoint := fmt.Sprintf(single_member_path, list.ID, id)
+	return list.api.Request("PATCH", endpoint, nil, &body, nil)
+}
+
 func (list *ListResponse) DeleteMember(id string) (bool, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return false, err
@@ -234,6 +356,29 @@ func (list *ListResponse) DeleteMemberPermanent(id string) (bool, error) {
 	return list.api.RequestOk("POST", endpoint)
 }
 
+// GetMemberByEmail gets the member of the list with the given email address
+func (list *ListResponse) GetMemberByEmail(email string, params *BasicQueryParams) (*Member, error) {
+	return list.GetMember(SubscriberHash(email), params)
+}
+
+// UpsertMemberByEmail adds a member with the given email address to the list
+// or updates it if it is already on the list. body.StatusIfNew is the status
+// used for a new member and body.EmailAddress defaults to email.
+func (list *ListResponse) UpsertMemberByEmail(email string, body *MemberRequest) (*Member, error) {
+	if body.EmailAddress == "" {
+		withEmail := *body
+		withEmail.EmailAddress = strings.TrimSpace(email)
+		body = &withEmail
+	}
+
+	return list.AddOrUpdateMember(SubscriberHash(email), body)
+}
+
+// DeleteMemberByEmail archives the member of the list with the given email address
+func (list *ListResponse) DeleteMemberByEmail(email string) (bool, error) {
+	return list.DeleteMember(SubscriberHash(email))
+}
+
 // ------------------------------------------------------------------------------------------------
 // Activity
 // ------------------------------------------------------------------------------------------------
@@ -241,21 +386,23 @@ func (list *ListResponse) DeleteMemberPermanent(id string) (bool, error) {
 type ListOfMemberActivity struct {
 	baseList
 
-	EmailID  string     `json:"email_id"`
-	ListID   string     `json:"list_id"`
-	Activity []Activity `json:"activity"`
+	EmailID  string           `json:"email_id"`
+	ListID   string           `json:"list_id"`
+	Activity []MemberActivity `json:"activity"`
 }
 
 type MemberActivity struct {
-	Action         string `json:"action"`
-	Timestamp      string `json:"timestamp"`
-	URL            string `json:"url"`
-	Type           string `json:"type"`
-	CampaignID     string `json:"campaign_id"`
-	Title          string `json:"title"`
-	ParentCampaign string `json:"parent_campaign"`
+	Action         MemberActivityAction `json:"action"`
+	Timestamp      string               `json:"timestamp"`
+	URL            string               `json:"url"`
+	Type           string               `json:"type"`
+	CampaignID     string               `json:"campaign_id"`
+	Title          string               `json:"title"`
+	ParentCampaign string               `json:"parent_campaign"`
 }
 
+// GetActivity returns the last 50 actions of the member, see GetActivityFeed
+// for the complete history.
 func (mem *Member) GetActivity(params *BasicQueryParams) (*ListOfMemberActivity, error) {
 	if err := mem.CanMakeRequest(); err != nil {
 		return nil, err
@@ -0,0 +1,146 @@
+package gochimp3
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriberHash(t *testing.T) {
+	cases := map[string]string{
+		"urist.mcvankab@freddiesjokes.com":       "62eeb292278cc15f5817cb78f7790b08",
+		"Urist.McVankab@FreddiesJokes.com":       "62eeb292278cc15f5817cb78f7790b08",
+		" urist.mcvankab@freddiesjokes.com\t\n ": "62eeb292278cc15f5817cb78f7790b08",
+		"jörg.müller@example.de":                 "02e0dcb817c839138e0449088d05b941",
+		"JÖRG.Müller@Example.DE":                 "02e0dcb817c839138e0449088d05b941",
+		"\u00a0jörg.müller@example.de\u2003":     "02e0dcb817c839138e0449088d05b941",
+		"用户@例子.广告":                               "9dd0518bb336cf3f6781e0ae09977e4e",
+	}
+
+	for email, hash := range cases {
+		assert.Equal(t, hash, SubscriberHash(email), email)
+	}
+
+	member := new(Member).SetIdByMail("URIST.mcvankab@freddiesjokes.com")
+	assert.Equal(t, "62eeb292278cc15f5817cb78f7790b08", member.ID)
+}
+
+func TestMemberQueryParams(t *testing.T) {
+	params := &MemberQueryParams{
+		SinceLastChanged: time.Date(2020, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)),
+		InterestIDs:      []string{"a1", "b2"},
+		InterestMatch:    INTEREST_MATCH_ALL,
+		VIPOnly:          true,
+	}
+	params.Status = MEMBER_STATUS_SUBSCRIBED
+	params.SortField = MEMBER_SORT_LAST_CHANGED
+	params.Count = 50
+
+	m := params.Params()
+	assert.Equal(t, "2020-03-01T12:30:00+01:00", m["since_last_changed"])
+	assert.Equal(t, "", m["before_last_changed"])
+	assert.Equal(t, "a1,b2", m["interest_ids"])
+	assert.Equal(t, "all", m["interest_match"])
+	assert.Equal(t, "true", m["vip_only"])
+	assert.Equal(t, "", m["since_last_campaign"])
+	assert.Equal(t, "subscribed", m["status"])
+	assert.Equal(t, "last_changed", m["sort_field"])
+	assert.Equal(t, "50", m["count"])
+}
+
+func TestMarketingPermissionConsent(t *testing.T) {
+	perms := MarketingPermissions{
+		{MarketingPermissionID: "p1", Text: "Email", Enabled: true},
+		{MarketingPermissionID: "p2", Text: "Customized Online Advertising", Enabled: false},
+	}
+
+	changes, err := perms.WithConsent(map[string]bool{" email ": true, "customized online advertising": true})
+	fatalIf(t, err)
+	assert.Equal(t, MarketingPermissions{
+		{MarketingPermissionID: "p1", Text: "Email", Enabled: true},
+		{MarketingPermissionID: "p2", Text: "Customized Online Advertising", Enabled: true},
+	}, changes)
+
+	_, err = perms.WithConsent(map[string]bool{"Direct Mail": true})
+	assert.Error(t, err)
+
+	buf := new(bytes.Buffer)
+	list := &ListResponse{ID: "list1"}
+	fatalIf(t, list.auditConsent(NewJSONConsentAuditLog(buf), "ada@example.com", perms, changes))
+
+	// only the permission which changed is recorded
+	entry := ConsentAuditEntry{}
+	fatalIf(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "p2", entry.MarketingPermissionID)
+	assert.True(t, entry.Enabled)
+	assert.False(t, *entry.Previous)
+}
+
+func TestSubscribeWithConsentOnEmptyList(t *testing.T) {
+	hash := SubscriberHash("ada@example.com")
+	var sent MemberRequest
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/members", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfMembers{})
+	})
+	mux.HandleFunc("/lists/list1/members/"+hash, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.WriteHeader(404)
+			writeJSON(w, &APIError{Status: 404, Type: "not found", Title: "Resource Not Found"})
+			return
+		}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&sent))
+		member := &Member{ID: hash, ListID: "list1"}
+		member.EmailAddress = sent.EmailAddress
+		writeJSON(w, member)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+	list := api.NewListResponse("list1")
+
+	body := &MemberRequest{EmailAddress: "ada@example.com", StatusIfNew: MEMBER_STATUS_SUBSCRIBED}
+	_, err := list.SubscribeWithConsent(body, map[string]bool{"Email": true}, nil)
+	assert.EqualError(t, err, "The list has no member to read marketing permissions from, their IDs must be provided")
+
+	body.MarketingPermissions = &MarketingPermissions{{MarketingPermissionID: "p1", Text: "Email"}}
+	member, err := list.SubscribeWithConsent(body, map[string]bool{"Email": true}, nil)
+	fatalIf(t, err)
+	assert.Equal(t, "ada@example.com", member.EmailAddress)
+	assert.Equal(t, &MarketingPermissions{{MarketingPermissionID: "p1", Text: "Email", Enabled: true}}, sent.MarketingPermissions)
+}
+
+func TestMemberActivityFeed(t *testing.T) {
+	hash := SubscriberHash("ada@example.com")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/members/"+hash+"/activity-feed", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "open,click", r.URL.Query().Get("activity_filters"))
+		feed := &ListOfMemberActivityFeed{Activity: MemberActivityFeed{
+			{ActivityType: MEMBER_ACTIVITY_OPEN, CreatedAtTimestamp: "2019-03-01T10:00:00+00:00"},
+			{ActivityType: MEMBER_ACTIVITY_CLICK, CreatedAtTimestamp: "2019-03-04T10:00:00+00:00"},
+			{ActivityType: MEMBER_ACTIVITY_OPEN, CreatedAtTimestamp: "2019-03-02T10:00:00+00:00"},
+		}}
+		feed.TotalItems = 3
+		writeJSON(w, feed)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	member := &Member{ID: hash, ListID: "list1", api: api}
+	last, err := member.LastEngagedAt()
+	fatalIf(t, err)
+	assert.Equal(t, time.Date(2019, 3, 4, 10, 0, 0, 0, time.UTC), last.UTC())
+
+	feed, err := member.GetAllActivityFeed(engagementActions...)
+	fatalIf(t, err)
+	assert.Len(t, feed.Filter(MEMBER_ACTIVITY_OPEN), 2)
+	assert.Len(t, feed.Between(time.Date(2019, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2019, 3, 4, 10, 0, 0, 0, time.UTC)), 1)
+	assert.True(t, MemberActivityFeed{}.LastEngagedAt().IsZero())
+}
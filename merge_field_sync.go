@@ -0,0 +1,188 @@
+package gochimp3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergeFieldUpdate is a merge field whose settings differ from the desired ones
+type MergeFieldUpdate struct {
+	Current MergeField
+	Desired MergeFieldRequest
+}
+
+// MergeFieldPlan lists the changes needed to make the merge fields of a list
+// match a desired schema.
+type MergeFieldPlan struct {
+	Create []MergeFieldRequest
+	Update []MergeFieldUpdate
+	Delete []MergeField
+}
+
+// IsEmpty returns true when the list already matches the desired schema
+func (plan *MergeFieldPlan) IsEmpty() bool {
+	return len(plan.Create) == 0 && len(plan.Update) == 0 && len(plan.Delete) == 0
+}
+
+// GetAllMergeFields pages through every merge field of the list
+func (list *ListResponse) GetAllMergeFields() ([]MergeField, error) {
+	params := &MergeFieldsParams{}
+
+	var fields []MergeField
+	err := pageThrough(&params.ExtendedQueryParams, func() (int, int, error) {
+		page, err := list.GetMergeFields(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		fields = append(fields, page.MergeFields...)
+		return len(page.MergeFields), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// PlanMergeFields compares the merge fields of the list with desired, matching
+// them by tag, without changing anything.
+func (list *ListResponse) PlanMergeFields(desired []MergeFieldRequest) (*MergeFieldPlan, error) {
+	current, err := list.GetAllMergeFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return planMergeFields(current, desired)
+}
+
+// SyncMergeFields creates, updates and deletes merge fields so the list ends up
+// with exactly the desired ones. Merge fields of the list which are not in
+// desired are deleted, along with their values on every member. The type of an
+// existing merge field can not be changed, such a mismatch is reported as an
+// error before anything is applied.
+//
+// The returned plan is the one that was applied, on error it may have been
+// applied only partially.
+func (list *ListResponse) SyncMergeFields(desired []MergeFieldRequest) (*MergeFieldPlan, error) {
+	plan, err := list.PlanMergeFields(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, list.ApplyMergeFieldPlan(plan)
+}
+
+// ApplyMergeFieldPlan applies the deletes, updates and creates of plan in that
+// order, stopping at the first error.
+func (list *ListResponse) ApplyMergeFieldPlan(plan *MergeFieldPlan) error {
+	for _, field := range plan.Delete {
+		if _, err := list.DeleteMergeField(field.MergeID); err != nil {
+			return fmt.Errorf("deleting merge field %s: %v", field.Tag, err)
+		}
+	}
+
+	for _, update := range plan.Update {
+		body := update.Desired
+		body.Type = ""
+		if _, err := list.UpdateMergeField(update.Current.MergeID, &body); err != nil {
+			return fmt.Errorf("updating merge field %s: %v", update.Current.Tag, err)
+		}
+	}
+
+	for i := range plan.Create {
+		if _, err := list.CreateMergeField(&plan.Create[i]); err != nil {
+			return fmt.Errorf("creating merge field %s: %v", plan.Create[i].Tag, err)
+		}
+	}
+
+	return nil
+}
+
+func planMergeFields(current []MergeField, desired []MergeFieldRequest) (*MergeFieldPlan, error) {
+	byTag := make(map[string]MergeField, len(current))
+	for _, field := range current {
+		byTag[strings.ToUpper(field.Tag)] = field
+	}
+
+	plan := new(MergeFieldPlan)
+	wanted := make(map[string]bool, len(desired))
+	for _, req := range desired {
+		tag := strings.ToUpper(req.Tag)
+		if tag == "" {
+			return nil, fmt.Errorf("merge field %q has no tag", req.Name)
+		}
+		if wanted[tag] {
+			return nil, fmt.Errorf("merge field %s is declared twice", tag)
+		}
+		wanted[tag] = true
+
+		field, ok := byTag[tag]
+		if !ok {
+			plan.Create = append(plan.Create, req)
+			continue
+		}
+
+		if req.Type != "" && req.Type != field.Type {
+			return nil, fmt.Errorf("merge field %s has type %s, can not change it to %s", tag, field.Type, req.Type)
+		}
+
+		if mergeFieldDiffers(field, req) {
+			plan.Update = append(plan.Update, MergeFieldUpdate{Current: field, Desired: req})
+		}
+	}
+
+	for _, field := range current {
+		if !wanted[strings.ToUpper(field.Tag)] {
+			plan.Delete = append(plan.Delete, field)
+		}
+	}
+
+	return plan, nil
+}
+
+// mergeFieldDiffers ignores the settings and options left unset in req,
+// Mailchimp fills them with defaults which would otherwise never match.
+func mergeFieldDiffers(field MergeField, req MergeFieldRequest) bool {
+	if field.Name != req.Name ||
+		field.DefaultValue != req.DefaultValue ||
+		field.HelpText != req.HelpText {
+		return true
+	}
+
+	switch {
+	case req.Required != nil && field.Required != *req.Required:
+		return true
+	case req.Public != nil && field.Public != *req.Public:
+		return true
+	case req.DisplayOrder != nil && field.DisplayOrder != *req.DisplayOrder:
+		return true
+	}
+
+	have, want := field.Options, req.Options
+	switch {
+	case want.DefaultCountry != 0 && have.DefaultCountry != want.DefaultCountry:
+		return true
+	case want.PhoneFormat != "" && have.PhoneFormat != want.PhoneFormat:
+		return true
+	case want.DateFormat != "" && have.DateFormat != want.DateFormat:
+		return true
+	case want.Size != 0 && have.Size != want.Size:
+		return true
+	case want.Choices != nil && !equalStrings(have.Choices, want.Choices):
+		return true
+	}
+
+	return false
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanMergeFields(t *testing.T) {
+	current := []MergeField{
+		{MergeID: 1, Tag: "FNAME", Name: "First Name", Type: "text", Public: true, DisplayOrder: 2, Options: MergeFieldOptions{Size: 25}},
+		{MergeID: 2, Tag: "LNAME", Name: "Last Name", Type: "text", Public: true, DisplayOrder: 3, Options: MergeFieldOptions{Size: 25}},
+		{MergeID: 3, Tag: "PLAN", Name: "Plan", Type: "dropdown", Options: MergeFieldOptions{Choices: []string{"free", "pro"}}},
+	}
+
+	fname := *NewMergeFieldRequest("fname", "First Name", MERGE_FIELD_TYPE_TEXT, nil)
+	fname.Public = Bool(true)
+	plan := *NewMergeFieldRequest("PLAN", "Plan", MERGE_FIELD_TYPE_DROPDOWN, ChoiceOptions{Choices: []string{"free", "pro", "team"}})
+	bday := *NewMergeFieldRequest("BDAY", "Birthday", MERGE_FIELD_TYPE_BIRTHDAY, DateOptions{DateFormat: BIRTHDAY_FORMAT_DAY_FIRST})
+
+	result, err := planMergeFields(current, []MergeFieldRequest{fname, plan, bday})
+	fatalIf(t, err)
+
+	assert.Equal(t, []MergeFieldRequest{bday}, result.Create)
+	if assert.Len(t, result.Update, 1) {
+		assert.Equal(t, 3, result.Update[0].Current.MergeID)
+	}
+	if assert.Len(t, result.Delete, 1) {
+		assert.Equal(t, "LNAME", result.Delete[0].Tag)
+	}
+
+	result, err = planMergeFields(current[:1], []MergeFieldRequest{fname})
+	fatalIf(t, err)
+	assert.True(t, result.IsEmpty())
+
+	_, err = planMergeFields(current, []MergeFieldRequest{*NewMergeFieldRequest("FNAME", "First Name", MERGE_FIELD_TYPE_NUMBER, nil)})
+	assert.Error(t, err)
+
+	_, err = planMergeFields(current, []MergeFieldRequest{fname, fname})
+	assert.Error(t, err)
+
+	fname.DisplayOrder = Int(5)
+	result, err = planMergeFields(current[:1], []MergeFieldRequest{fname})
+	fatalIf(t, err)
+	assert.Len(t, result.Update, 1)
+
+	// unset settings are left out of updates
+	data, err := json.Marshal(NewMergeFieldRequest("FNAME", "First Name", "", nil))
+	fatalIf(t, err)
+	body := map[string]interface{}{}
+	fatalIf(t, json.Unmarshal(data, &body))
+	assert.NotContains(t, body, "required")
+	assert.NotContains(t, body, "public")
+	assert.NotContains(t, body, "display_order")
+}
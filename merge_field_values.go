@@ -0,0 +1,371 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isoDateFormat is the format Mailchimp always accepts for date merge fields
+const isoDateFormat = "YYYY-MM-DD"
+
+// ErrMergeFieldNotSet is returned when reading a merge field without a value
+var ErrMergeFieldNotSet = errors.New("merge field not set")
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	addressType = reflect.TypeOf(MergeFieldAddress{})
+)
+
+// MergeFields holds the merge field values of a member keyed by merge tag.
+// Mailchimp returns an empty string for merge fields without a value.
+type MergeFields map[string]interface{}
+
+// MergeFieldAddress is the value of an address merge field
+type MergeFieldAddress struct {
+	Addr1   string `json:"addr1"`
+	Addr2   string `json:"addr2,omitempty"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	Zip     string `json:"zip"`
+	Country string `json:"country,omitempty"`
+}
+
+func (mf MergeFields) value(tag string) (interface{}, error) {
+	v, ok := mf[tag]
+	if !ok || v == nil || v == "" {
+		return nil, ErrMergeFieldNotSet
+	}
+	return v, nil
+}
+
+// GetString returns the value of a text, url, phone, zip or choice merge field
+func (mf MergeFields) GetString(tag string) (string, error) {
+	v, err := mf.value(tag)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("merge field %s is a %T, not a string", tag, v)
+}
+
+// GetNumber returns the value of a number merge field
+func (mf MergeFields) GetNumber(tag string) (float64, error) {
+	v, err := mf.value(tag)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("merge field %s is a %T, not a number", tag, v)
+}
+
+// GetAddress returns the value of an address merge field
+func (mf MergeFields) GetAddress(tag string) (*MergeFieldAddress, error) {
+	v, err := mf.value(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case MergeFieldAddress:
+		return &v, nil
+	case *MergeFieldAddress:
+		return v, nil
+	case map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		address := new(MergeFieldAddress)
+		return address, json.Unmarshal(data, address)
+	}
+	return nil, fmt.Errorf("merge field %s is a %T, not an address", tag, v)
+}
+
+// GetDate returns the value of a date or birthday merge field. dateFormat is
+// the DateFormat from the options of the merge field, values in YYYY-MM-DD
+// are accepted as well.
+func (mf MergeFields) GetDate(tag, dateFormat string) (time.Time, error) {
+	s, err := mf.GetString(tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := time.Parse(mergeFieldDateLayout(dateFormat), s)
+	if err != nil && dateFormat != "" {
+		t, err = time.Parse(mergeFieldDateLayout(isoDateFormat), s)
+	}
+	return t, err
+}
+
+// SetString sets the value of a text, url, phone, zip or choice merge field
+func (mf *MergeFields) SetString(tag, value string) {
+	mf.set(tag, value)
+}
+
+// SetNumber sets the value of a number merge field
+func (mf *MergeFields) SetNumber(tag string, value float64) {
+	mf.set(tag, value)
+}
+
+// SetAddress sets the value of an address merge field
+func (mf *MergeFields) SetAddress(tag string, value MergeFieldAddress) {
+	mf.set(tag, value)
+}
+
+// SetDate sets the value of a date or birthday merge field in dateFormat, the
+// DateFormat from the options of the merge field.
+func (mf *MergeFields) SetDate(tag string, value time.Time, dateFormat string) {
+	mf.set(tag, value.Format(mergeFieldDateLayout(dateFormat)))
+}
+
+func (mf *MergeFields) set(tag string, value interface{}) {
+	if *mf == nil {
+		*mf = make(MergeFields)
+	}
+	(*mf)[tag] = value
+}
+
+// mergeFieldDateLayout converts a Mailchimp date format such as MM/DD/YYYY to
+// a time layout.
+func mergeFieldDateLayout(dateFormat string) string {
+	if dateFormat == "" {
+		dateFormat = isoDateFormat
+	}
+	return strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(strings.ToUpper(dateFormat))
+}
+
+// ------------------------------------------------------------------------------------------------
+// Struct mapping
+// ------------------------------------------------------------------------------------------------
+
+// mergeFieldTag is a parsed `mc` struct tag, e.g. `mc:"BDAY,format=MM/DD,omitempty"`
+type mergeFieldTag struct {
+	Name      string
+	Format    string
+	OmitEmpty bool
+}
+
+func parseMergeFieldTag(tag string) mergeFieldTag {
+	parts := strings.Split(tag, ",")
+	parsed := mergeFieldTag{Name: parts[0]}
+	for _, opt := range parts[1:] {
+		switch {
+		case opt == "omitempty":
+			parsed.OmitEmpty = true
+		case strings.HasPrefix(opt, "format="):
+			parsed.Format = strings.TrimPrefix(opt, "format=")
+		}
+	}
+	return parsed
+}
+
+func mergeFieldStruct(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return rv, fmt.Errorf("merge fields can only be mapped to a struct, not %T", v)
+	}
+	return rv, nil
+}
+
+// Decode stores the merge field values in the fields of the struct pointed to
+// by v which have an `mc` tag naming the merge tag. Supported field types are
+// strings, numbers, MergeFieldAddress and time.Time, the layout of the latter
+// is given by a format option such as `mc:"BDAY,format=MM/DD"`.
+func (mf MergeFields) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("merge fields can only be decoded into a pointer to a struct, not %T", v)
+	}
+
+	rv, err := mergeFieldStruct(v)
+	if err != nil {
+		return err
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		tag, ok := rt.Field(i).Tag.Lookup("mc")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		parsed := parseMergeFieldTag(tag)
+		err := mf.decodeField(rv.Field(i), parsed)
+		if err == ErrMergeFieldNotSet {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("decoding merge field %s into %s: %v", parsed.Name, rt.Field(i).Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (mf MergeFields) decodeField(field reflect.Value, tag mergeFieldTag) error {
+	if field.Kind() == reflect.Ptr {
+		if _, err := mf.value(tag.Name); err != nil {
+			return err
+		}
+		ptr := reflect.New(field.Type().Elem())
+		if err := mf.decodeField(ptr.Elem(), tag); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	switch {
+	case field.Type() == timeType:
+		t, err := mf.GetDate(tag.Name, tag.Format)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case field.Type() == addressType:
+		address, err := mf.GetAddress(tag.Name)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(*address))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		s, err := mf.GetString(tag.Name)
+		if err != nil {
+			return err
+		}
+		field.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := mf.GetNumber(tag.Name)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := mf.GetNumber(tag.Name)
+		if err != nil {
+			return err
+		}
+		field.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		n, err := mf.GetNumber(tag.Name)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// Encode sets the merge field values from the `mc` tagged fields of the struct
+// v, see Decode for the supported field types.
+func (mf *MergeFields) Encode(v interface{}) error {
+	rv, err := mergeFieldStruct(v)
+	if err != nil {
+		return err
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		tag, ok := rt.Field(i).Tag.Lookup("mc")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		parsed := parseMergeFieldTag(tag)
+		field := rv.Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				if !parsed.OmitEmpty {
+					mf.set(parsed.Name, "")
+				}
+				continue
+			}
+			field = field.Elem()
+		} else if parsed.OmitEmpty && field.IsZero() {
+			continue
+		}
+
+		if err := mf.encodeField(field, parsed); err != nil {
+			return fmt.Errorf("encoding %s into merge field %s: %v", rt.Field(i).Name, parsed.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (mf *MergeFields) encodeField(field reflect.Value, tag mergeFieldTag) error {
+	switch {
+	case field.Type() == timeType:
+		t := field.Interface().(time.Time)
+		if t.IsZero() {
+			mf.set(tag.Name, "")
+		} else {
+			mf.SetDate(tag.Name, t, tag.Format)
+		}
+		return nil
+	case field.Type() == addressType:
+		mf.SetAddress(tag.Name, field.Interface().(MergeFieldAddress))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		mf.SetString(tag.Name, field.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		mf.SetNumber(tag.Name, float64(field.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		mf.SetNumber(tag.Name, float64(field.Uint()))
+	case reflect.Float32, reflect.Float64:
+		mf.SetNumber(tag.Name, field.Float())
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// DecodeMergeFields stores the merge fields of the member in the `mc` tagged
+// fields of the struct pointed to by v.
+func (mem *Member) DecodeMergeFields(v interface{}) error {
+	return mem.MergeFields.Decode(v)
+}
+
+// EncodeMergeFields sets the merge fields of the request from the `mc` tagged
+// fields of the struct v.
+func (req *MemberRequest) EncodeMergeFields(v interface{}) error {
+	return req.MergeFields.Encode(v)
+}
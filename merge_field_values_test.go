@@ -0,0 +1,88 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProfile struct {
+	FirstName string             `mc:"FNAME"`
+	Age       int                `mc:"AGE"`
+	Score     *float64           `mc:"SCORE,omitempty"`
+	Address   MergeFieldAddress  `mc:"ADDRESS"`
+	Birthday  time.Time          `mc:"BDAY,format=MM/DD"`
+	Signup    time.Time          `mc:"SIGNUP,format=DD/MM/YYYY,omitempty"`
+	Billing   *MergeFieldAddress `mc:"BILLING,omitempty"`
+	Ignored   string
+}
+
+func TestMergeFieldsGetters(t *testing.T) {
+	member := new(Member)
+	err := json.Unmarshal([]byte(`{"merge_fields": {
+		"FNAME": "Ada",
+		"AGE": 36,
+		"ADDRESS": {"addr1": "12 Main St", "city": "London", "state": "", "zip": "N1", "country": "GB"},
+		"SIGNUP": "31/12/2019",
+		"EMPTY": ""
+	}}`), member)
+	fatalIf(t, err)
+
+	s, err := member.MergeFields.GetString("FNAME")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ada", s)
+
+	n, err := member.MergeFields.GetNumber("AGE")
+	assert.NoError(t, err)
+	assert.Equal(t, 36.0, n)
+
+	address, err := member.MergeFields.GetAddress("ADDRESS")
+	assert.NoError(t, err)
+	assert.Equal(t, "London", address.City)
+
+	date, err := member.MergeFields.GetDate("SIGNUP", DATE_FORMAT_DAY_FIRST)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC), date)
+
+	_, err = member.MergeFields.GetString("EMPTY")
+	assert.Equal(t, ErrMergeFieldNotSet, err)
+	_, err = member.MergeFields.GetNumber("MISSING")
+	assert.Equal(t, ErrMergeFieldNotSet, err)
+	_, err = member.MergeFields.GetNumber("FNAME")
+	assert.Error(t, err)
+}
+
+func TestMergeFieldsStructMapping(t *testing.T) {
+	profile := testProfile{
+		FirstName: "Ada",
+		Age:       36,
+		Address:   MergeFieldAddress{Addr1: "12 Main St", City: "London", Zip: "N1"},
+		Birthday:  time.Date(0, 12, 10, 0, 0, 0, 0, time.UTC),
+		Ignored:   "nope",
+	}
+
+	req := new(MemberRequest)
+	fatalIf(t, req.EncodeMergeFields(&profile))
+	assert.Equal(t, "Ada", req.MergeFields["FNAME"])
+	assert.Equal(t, 36.0, req.MergeFields["AGE"])
+	assert.Equal(t, "12/10", req.MergeFields["BDAY"])
+	assert.NotContains(t, req.MergeFields, "SCORE")
+	assert.NotContains(t, req.MergeFields, "SIGNUP")
+	assert.NotContains(t, req.MergeFields, "BILLING")
+	assert.Len(t, req.MergeFields, 4)
+
+	// round trip through the API representation
+	data, err := json.Marshal(req)
+	fatalIf(t, err)
+	member := new(Member)
+	fatalIf(t, json.Unmarshal(data, member))
+
+	decoded := testProfile{}
+	fatalIf(t, member.DecodeMergeFields(&decoded))
+	profile.Ignored = ""
+	assert.Equal(t, profile, decoded)
+
+	assert.Error(t, member.DecodeMergeFields(decoded))
+}
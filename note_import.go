@@ -0,0 +1,465 @@
+package gochimp3
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNoteBatchSize    = 1000
+	defaultNotePollInterval = 5 * time.Second
+	defaultNoteWaitTimeout  = time.Hour
+)
+
+// NoteRecord is a note to import on the member with the given email.
+// Mailchimp sets the creation date of notes, Timestamp is only kept in the
+// text of the note by the default NoteImporter.Format.
+type NoteRecord struct {
+	Email     string `json:"email"`
+	Note      string `json:"note"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// ReadNoteRecordsCSV reads notes from a CSV with a header naming the email,
+// note and optional timestamp columns.
+func ReadNoteRecordsCSV(r io.Reader) ([]NoteRecord, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{"timestamp": -1}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"email", "note"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("The notes CSV has no %s column", name)
+		}
+	}
+
+	var records []NoteRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := NoteRecord{Email: row[columns["email"]], Note: row[columns["note"]]}
+		if i := columns["timestamp"]; i >= 0 {
+			record.Timestamp = row[i]
+		}
+		records = append(records, record)
+	}
+}
+
+// ReadNoteRecordsJSONL reads notes from lines of JSON objects
+func ReadNoteRecordsJSONL(r io.Reader) ([]NoteRecord, error) {
+	var records []NoteRecord
+	decoder := json.NewDecoder(r)
+	for {
+		var record NoteRecord
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// OperationID identifies the note on the list, so that importing the same
+// note twice can be detected.
+func (record *NoteRecord) OperationID(listID string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s", listID, strings.ToLower(strings.TrimSpace(record.Email)), record.Timestamp, record.Note)
+	return fmt.Sprintf("note-%x", h.Sum(nil)[:16])
+}
+
+// NoteImportLedger remembers the operation IDs of imported notes, and the
+// batches submitted whose results were not recorded yet, so an interrupted
+// import can reconcile them instead of creating their notes twice.
+type NoteImportLedger interface {
+	Imported(operationID string) bool
+
+	// Pending returns the operation IDs of the unfinished batches by batch ID
+	Pending() map[string][]string
+	MarkSubmitted(batchID string, operationIDs []string) error
+
+	// MarkFinished records the imported notes of the batch, which is no
+	// longer pending. A batch which can't be reconciled is finished with no
+	// imported notes.
+	MarkFinished(batchID string, imported []string) error
+}
+
+// FileNoteImportLedger keeps the ledger in a file, with a line for every
+// imported operation ID and a line for every batch submitted and finished.
+type FileNoteImportLedger struct {
+	mu       sync.Mutex
+	file     *os.File
+	imported map[string]bool
+	pending  map[string][]string
+}
+
+const (
+	noteLedgerSubmitted = "submitted"
+	noteLedgerFinished  = "finished"
+)
+
+// OpenFileNoteImportLedger loads the ledger at path, creating it if needed
+func OpenFileNoteImportLedger(path string) (*FileNoteImportLedger, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := &FileNoteImportLedger{
+		file:     file,
+		imported: make(map[string]bool),
+		pending:  make(map[string][]string),
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1:
+			ledger.imported[fields[0]] = true
+		case len(fields) > 1 && fields[0] == noteLedgerSubmitted:
+			ledger.pending[fields[1]] = fields[2:]
+		case len(fields) == 2 && fields[0] == noteLedgerFinished:
+			delete(ledger.pending, fields[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return ledger, nil
+}
+
+func (ledger *FileNoteImportLedger) Imported(operationID string) bool {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	return ledger.imported[operationID]
+}
+
+func (ledger *FileNoteImportLedger) Pending() map[string][]string {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	pending := make(map[string][]string, len(ledger.pending))
+	for id, operationIDs := range ledger.pending {
+		pending[id] = operationIDs
+	}
+	return pending
+}
+
+func (ledger *FileNoteImportLedger) MarkSubmitted(batchID string, operationIDs []string) error {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	line := strings.Join(append([]string{noteLedgerSubmitted, batchID}, operationIDs...), " ")
+	if err := ledger.write(line); err != nil {
+		return err
+	}
+	ledger.pending[batchID] = operationIDs
+	return nil
+}
+
+func (ledger *FileNoteImportLedger) MarkFinished(batchID string, imported []string) error {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	lines := append(append([]string{}, imported...), noteLedgerFinished+" "+batchID)
+	if err := ledger.write(strings.Join(lines, "\n")); err != nil {
+		return err
+	}
+	for _, id := range imported {
+		ledger.imported[id] = true
+	}
+	delete(ledger.pending, batchID)
+	return nil
+}
+
+func (ledger *FileNoteImportLedger) write(lines string) error {
+	if _, err := ledger.file.WriteString(lines + "\n"); err != nil {
+		return err
+	}
+	return ledger.file.Sync()
+}
+
+func (ledger *FileNoteImportLedger) Close() error {
+	return ledger.file.Close()
+}
+
+// NoteImportFailure is a note Mailchimp refused
+type NoteImportFailure struct {
+	Record      NoteRecord `json:"record"`
+	OperationID string     `json:"operation_id"`
+	StatusCode  int        `json:"status_code"`
+	Error       string     `json:"error"`
+}
+
+// NoteImportReport sums up an import. Skipped counts the notes already in
+// the ledger or repeated in the input, Recovered the notes imported by the
+// batches of an interrupted import. AbandonedBatchIDs are the batches of an
+// interrupted import which were purged by Mailchimp or did not finish in time,
+// their notes are submitted again and may be duplicated.
+type NoteImportReport struct {
+	Imported          int                 `json:"imported"`
+	Skipped           int                 `json:"skipped"`
+	Recovered         int                 `json:"recovered"`
+	Failures          []NoteImportFailure `json:"failures,omitempty"`
+	BatchIDs          []string            `json:"batch_ids"`
+	AbandonedBatchIDs []string            `json:"abandoned_batch_ids,omitempty"`
+}
+
+// NoteImporter creates member notes in bulk through batch operations
+type NoteImporter struct {
+	List   *ListResponse
+	Ledger NoteImportLedger
+
+	// Format returns the text of the note created for a record.
+	Format func(NoteRecord) string
+
+	BatchSize    int
+	PollInterval time.Duration
+
+	// WaitTimeout is how long a batch may run, the import fails after it
+	// and the batch is reconciled by the next import.
+	WaitTimeout time.Duration
+}
+
+func (list *ListResponse) NewNoteImporter(ledger NoteImportLedger) *NoteImporter {
+	return &NoteImporter{
+		List:         list,
+		Ledger:       ledger,
+		Format:       formatNoteRecord,
+		BatchSize:    defaultNoteBatchSize,
+		PollInterval: defaultNotePollInterval,
+		WaitTimeout:  defaultNoteWaitTimeout,
+	}
+}
+
+// formatNoteRecord prefixes the note with its original timestamp
+func formatNoteRecord(record NoteRecord) string {
+	if record.Timestamp == "" {
+		return record.Note
+	}
+	return fmt.Sprintf("[%s] %s", record.Timestamp, record.Note)
+}
+
+// Import submits the notes not in the ledger yet in batches, waits for each
+// batch to finish and records the imported notes in the ledger, so an
+// interrupted import can be run again. The batches left pending by an
+// interrupted import are waited for first, their failed notes are submitted
+// again, see NoteImportReport.AbandonedBatchIDs.
+func (importer *NoteImporter) Import(records []NoteRecord) (*NoteImportReport, error) {
+	if err := importer.List.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+	if importer.Ledger == nil {
+		return nil, errors.New("A note import needs a Ledger")
+	}
+
+	batchSize := importer.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultNoteBatchSize
+	}
+	format := importer.Format
+	if format == nil {
+		format = formatNoteRecord
+	}
+
+	report := &NoteImportReport{BatchIDs: []string{}}
+	if err := importer.reconcile(report); err != nil {
+		return report, err
+	}
+
+	pending := make(map[string]NoteRecord)
+	var operations []BatchOperation
+
+	for _, record := range records {
+		id := record.OperationID(importer.List.ID)
+		if _, ok := pending[id]; ok || importer.Ledger.Imported(id) {
+			report.Skipped++
+			continue
+		}
+
+		body, err := json.Marshal(struct {
+			Note string `json:"note"`
+		}{format(record)})
+		if err != nil {
+			return report, err
+		}
+
+		pending[id] = record
+		operations = append(operations, BatchOperation{
+			Method:      "POST",
+			Path:        fmt.Sprintf(member_notes_path, importer.List.ID, SubscriberHash(record.Email)),
+			Body:        string(body),
+			OperationID: id,
+		})
+
+		if len(operations) == batchSize {
+			if err := importer.submit(operations, pending, report); err != nil {
+				return report, err
+			}
+			operations = nil
+		}
+	}
+
+	if len(operations) > 0 {
+		if err := importer.submit(operations, pending, report); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// reconcile finishes the batches submitted by an interrupted import
+func (importer *NoteImporter) reconcile(report *NoteImportReport) error {
+	pending := importer.Ledger.Pending()
+	batchIDs := make([]string, 0, len(pending))
+	for id := range pending {
+		batchIDs = append(batchIDs, id)
+	}
+	sort.Strings(batchIDs)
+
+	for _, id := range batchIDs {
+		submitted := make(map[string]bool, len(pending[id]))
+		for _, operationID := range pending[id] {
+			submitted[operationID] = true
+		}
+
+		batch, err := importer.List.api.GetBatchOperation(id, nil)
+		var results []BatchOperationResult
+		if err == nil {
+			results, err = importer.finish(batch, submitted)
+		}
+
+		// Mailchimp purges batches after about a week, such a batch or one
+		// which is stuck can't be reconciled
+		if isNotFound(err) || err == ErrBatchTimeout {
+			if err := importer.Ledger.MarkFinished(id, nil); err != nil {
+				return err
+			}
+			report.AbandonedBatchIDs = append(report.AbandonedBatchIDs, id)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("Reconciling the note batch %s: %v", id, err)
+		}
+		for i := range results {
+			if results[i].Succeeded() {
+				report.Recovered++
+			}
+		}
+		report.BatchIDs = append(report.BatchIDs, id)
+	}
+	return nil
+}
+
+func (importer *NoteImporter) submit(operations []BatchOperation, pending map[string]NoteRecord, report *NoteImportReport) error {
+	operationIDs := make([]string, len(operations))
+	submitted := make(map[string]bool, len(operations))
+	for i, operation := range operations {
+		operationIDs[i] = operation.OperationID
+		submitted[operation.OperationID] = true
+	}
+
+	batch, err := importer.List.api.CreateBatchOperation(&BatchOperationCreationRequest{Operations: operations})
+	if err != nil {
+		return err
+	}
+	report.BatchIDs = append(report.BatchIDs, batch.ID)
+
+	if err := importer.Ledger.MarkSubmitted(batch.ID, operationIDs); err != nil {
+		return err
+	}
+
+	results, err := importer.finish(batch, submitted)
+	if err != nil {
+		return err
+	}
+
+	answered := make(map[string]bool, len(results))
+	for i := range results {
+		result := &results[i]
+		answered[result.OperationID] = true
+
+		if result.Succeeded() {
+			report.Imported++
+			continue
+		}
+		report.Failures = append(report.Failures, NoteImportFailure{
+			Record:      pending[result.OperationID],
+			OperationID: result.OperationID,
+			StatusCode:  result.StatusCode,
+			Error:       result.Error().Error(),
+		})
+	}
+
+	for _, operation := range operations {
+		if !answered[operation.OperationID] {
+			report.Failures = append(report.Failures, NoteImportFailure{
+				Record:      pending[operation.OperationID],
+				OperationID: operation.OperationID,
+				Error:       "No result returned for the operation",
+			})
+		}
+		delete(pending, operation.OperationID)
+	}
+
+	return nil
+}
+
+// finish waits for the batch, records its imported notes in the ledger and
+// returns the results of the submitted operations
+func (importer *NoteImporter) finish(batch *BatchOperationResponse, submitted map[string]bool) ([]BatchOperationResult, error) {
+	interval := importer.PollInterval
+	if interval <= 0 {
+		interval = defaultNotePollInterval
+	}
+	if err := batch.Wait(interval, importer.WaitTimeout); err != nil {
+		return nil, err
+	}
+
+	all, err := batch.GetResults()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []BatchOperationResult
+	var imported []string
+	for _, result := range all {
+		if !submitted[result.OperationID] {
+			continue
+		}
+		results = append(results, result)
+		if result.Succeeded() {
+			imported = append(imported, result.OperationID)
+		}
+	}
+
+	return results, importer.Ledger.MarkFinished(batch.ID, imported)
+}
@@ -0,0 +1,230 @@
+package gochimp3
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeBatchResults(w http.ResponseWriter, results []BatchOperationResult) {
+	data, _ := json.Marshal(results)
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	archive.WriteHeader(&tar.Header{Name: "results/1.json", Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+	archive.Write(data)
+	archive.Close()
+	gz.Close()
+}
+
+func TestNoteImport(t *testing.T) {
+	records, err := ReadNoteRecordsCSV(strings.NewReader(
+		"Email,Note,Timestamp\n" +
+			"ada@example.com,called support,2019-01-02\n" +
+			"bob@example.com,bounced,\n" +
+			"ada@example.com,called support,2019-01-02\n"))
+	fatalIf(t, err)
+	assert.Len(t, records, 3)
+
+	var batch BatchOperationCreationRequest
+	var results []BatchOperationResult
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/batches", func(w http.ResponseWriter, r *http.Request) {
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&batch))
+		results = nil
+		for _, operation := range batch.Operations {
+			result := BatchOperationResult{StatusCode: 200, OperationID: operation.OperationID, Response: "{}"}
+			if strings.Contains(operation.Path, SubscriberHash("bob@example.com")) {
+				result.StatusCode = 404
+				result.Response = `{"status":404,"title":"Resource Not Found","detail":"unknown member"}`
+			}
+			results = append(results, result)
+		}
+		writeJSON(w, map[string]string{"id": "batch1", "status": BATCH_STATUS_PENDING})
+	})
+	mux.HandleFunc("/batches/batch1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"id": "batch1", "status": BATCH_STATUS_FINISHED, "response_body_url": "http://" + r.Host + "/results"})
+	})
+	mux.HandleFunc("/results", func(w http.ResponseWriter, r *http.Request) {
+		writeBatchResults(w, results)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "notes")
+	fatalIf(t, err)
+	defer os.RemoveAll(dir)
+
+	ledger, err := OpenFileNoteImportLedger(filepath.Join(dir, "ledger"))
+	fatalIf(t, err)
+
+	importer := api.NewListResponse("list1").NewNoteImporter(ledger)
+	importer.PollInterval = 1
+
+	report, err := importer.Import(records)
+	fatalIf(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, []string{"batch1"}, report.BatchIDs)
+	if assert.Len(t, report.Failures, 1) {
+		assert.Equal(t, "bob@example.com", report.Failures[0].Record.Email)
+		assert.Equal(t, 404, report.Failures[0].StatusCode)
+	}
+	assert.Equal(t, `{"note":"[2019-01-02] called support"}`, batch.Operations[0].Body)
+	fatalIf(t, ledger.Close())
+
+	ledger, err = OpenFileNoteImportLedger(filepath.Join(dir, "ledger"))
+	fatalIf(t, err)
+	defer ledger.Close()
+
+	importer.Ledger = ledger
+	report, err = importer.Import(records)
+	fatalIf(t, err)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Len(t, batch.Operations, 1)
+	assert.Equal(t, "POST", batch.Operations[0].Method)
+}
+
+func TestNoteImportReconcile(t *testing.T) {
+	records := []NoteRecord{{Email: "ada@example.com", Note: "called support"}, {Email: "bob@example.com", Note: "bounced"}}
+	ada := records[0].OperationID("list1")
+	bob := records[1].OperationID("list1")
+
+	var submitted []string
+	unavailable := true
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/batches", func(w http.ResponseWriter, r *http.Request) {
+		batch := BatchOperationCreationRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&batch))
+		for _, operation := range batch.Operations {
+			submitted = append(submitted, operation.OperationID)
+		}
+		writeJSON(w, map[string]string{"id": "batch2", "status": BATCH_STATUS_PENDING})
+	})
+	mux.HandleFunc("/batches/batch1", func(w http.ResponseWriter, r *http.Request) {
+		if unavailable {
+			http.Error(w, `{"status": 503, "title": "Service Unavailable"}`, 503)
+			return
+		}
+		writeJSON(w, map[string]string{"id": "batch1", "status": BATCH_STATUS_FINISHED, "response_body_url": "http://" + r.Host + "/results1"})
+	})
+	mux.HandleFunc("/results1", func(w http.ResponseWriter, r *http.Request) {
+		writeBatchResults(w, []BatchOperationResult{
+			{StatusCode: 200, OperationID: ada, Response: "{}"},
+			{StatusCode: 500, OperationID: bob, Response: "{}"},
+		})
+	})
+	mux.HandleFunc("/batches/batch2", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"id": "batch2", "status": BATCH_STATUS_FINISHED, "response_body_url": "http://" + r.Host + "/results2"})
+	})
+	mux.HandleFunc("/results2", func(w http.ResponseWriter, r *http.Request) {
+		writeBatchResults(w, []BatchOperationResult{{StatusCode: 200, OperationID: bob, Response: "{}"}})
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "notes")
+	fatalIf(t, err)
+	defer os.RemoveAll(dir)
+
+	// an import interrupted right after submitting its batch
+	ledger, err := OpenFileNoteImportLedger(filepath.Join(dir, "ledger"))
+	fatalIf(t, err)
+	fatalIf(t, ledger.MarkSubmitted("batch1", []string{ada, bob}))
+	fatalIf(t, ledger.Close())
+
+	ledger, err = OpenFileNoteImportLedger(filepath.Join(dir, "ledger"))
+	fatalIf(t, err)
+	defer ledger.Close()
+	assert.Equal(t, map[string][]string{"batch1": {ada, bob}}, ledger.Pending())
+
+	importer := api.NewListResponse("list1").NewNoteImporter(ledger)
+	importer.PollInterval = time.Millisecond
+	importer.WaitTimeout = 10 * time.Millisecond
+
+	// the batch can't be checked yet
+	_, err = importer.Import(records)
+	assert.Error(t, err)
+	assert.Empty(t, submitted)
+	assert.Len(t, ledger.Pending(), 1)
+
+	unavailable = false
+	report, err := importer.Import(records)
+	fatalIf(t, err)
+	assert.Equal(t, 1, report.Recovered)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, []string{"batch1", "batch2"}, report.BatchIDs)
+	assert.Equal(t, []string{bob}, submitted)
+	assert.Empty(t, ledger.Pending())
+	assert.True(t, ledger.Imported(ada))
+	assert.True(t, ledger.Imported(bob))
+}
+
+func TestNoteImportAbandonsBatches(t *testing.T) {
+	records := []NoteRecord{{Email: "ada@example.com", Note: "called support"}, {Email: "bob@example.com", Note: "bounced"}}
+	ada := records[0].OperationID("list1")
+	bob := records[1].OperationID("list1")
+
+	var submitted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/batches", func(w http.ResponseWriter, r *http.Request) {
+		batch := BatchOperationCreationRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&batch))
+		for _, operation := range batch.Operations {
+			submitted = append(submitted, operation.OperationID)
+		}
+		writeJSON(w, map[string]string{"id": "batch3", "status": BATCH_STATUS_PENDING})
+	})
+	mux.HandleFunc("/batches/purged", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		writeJSON(w, &APIError{Status: 404, Type: "not found", Title: "Resource Not Found"})
+	})
+	mux.HandleFunc("/batches/stuck", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"id": "stuck", "status": BATCH_STATUS_STARTED})
+	})
+	mux.HandleFunc("/batches/batch3", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"id": "batch3", "status": BATCH_STATUS_FINISHED, "response_body_url": "http://" + r.Host + "/results"})
+	})
+	mux.HandleFunc("/results", func(w http.ResponseWriter, r *http.Request) {
+		writeBatchResults(w, []BatchOperationResult{{StatusCode: 200, OperationID: ada, Response: "{}"}, {StatusCode: 200, OperationID: bob, Response: "{}"}})
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "notes")
+	fatalIf(t, err)
+	defer os.RemoveAll(dir)
+
+	ledger, err := OpenFileNoteImportLedger(filepath.Join(dir, "ledger"))
+	fatalIf(t, err)
+	defer ledger.Close()
+	fatalIf(t, ledger.MarkSubmitted("purged", []string{ada}))
+	fatalIf(t, ledger.MarkSubmitted("stuck", []string{bob}))
+
+	importer := api.NewListResponse("list1").NewNoteImporter(ledger)
+	importer.PollInterval = time.Millisecond
+	importer.WaitTimeout = 10 * time.Millisecond
+
+	report, err := importer.Import(records)
+	fatalIf(t, err)
+	assert.Equal(t, []string{"purged", "stuck"}, report.AbandonedBatchIDs)
+	assert.Equal(t, 0, report.Recovered)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, []string{ada, bob}, submitted)
+	assert.Empty(t, ledger.Pending())
+}
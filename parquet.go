@@ -0,0 +1,307 @@
+package gochimp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Parquet constants used by parquetWriter, from parquet.thrift
+const (
+	parquetMagic = "PAR1"
+
+	parquetTypeByteArray   = 6
+	parquetRequired        = 0
+	parquetConvertedUTF8   = 0
+	parquetEncodingPlain   = 0
+	parquetEncodingRLE     = 3
+	parquetUncompressed    = 0
+	parquetPageTypeData    = 0
+	parquetDefaultRowGroup = 10000
+)
+
+// parquetWriter writes a Parquet file of required UTF8 columns. Rows are
+// buffered until a row group is full, so only one row group is held in
+// memory. Pages are PLAIN encoded and not compressed. Column names are
+// sanitized, see parquetColumnNames.
+type parquetWriter struct {
+	w       io.Writer
+	offset  int64
+	columns []string
+
+	rowGroupSize int
+	pending      [][]string
+	rowGroups    []parquetRowGroup
+	numRows      int64
+}
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+}
+
+type parquetRowGroup struct {
+	numRows int64
+	chunks  []parquetColumnChunk
+}
+
+func newParquetWriter(w io.Writer, columns []string, rowGroupSize int) (*parquetWriter, error) {
+	if rowGroupSize <= 0 {
+		rowGroupSize = parquetDefaultRowGroup
+	}
+
+	pw := &parquetWriter{w: w, columns: parquetColumnNames(columns), rowGroupSize: rowGroupSize}
+	return pw, pw.write([]byte(parquetMagic))
+}
+
+// parquetColumnNames replaces the runs of characters other than letters,
+// digits and _ with a _, query engines read dots as nested fields, e.g.
+// merge_fields.FNAME becomes merge_fields_FNAME and interests.Topics > Rust
+// becomes interests_Topics_Rust. Names made equal get a numbered suffix.
+func parquetColumnNames(columns []string) []string {
+	names := make([]string, len(columns))
+	used := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		var name strings.Builder
+		replaced := false
+		for _, r := range column {
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				name.WriteRune(r)
+				replaced = false
+			} else if !replaced {
+				name.WriteByte('_')
+				replaced = true
+			}
+		}
+
+		unique := name.String()
+		for n := 2; used[unique]; n++ {
+			unique = fmt.Sprintf("%s_%d", name.String(), n)
+		}
+		used[unique] = true
+		names[i] = unique
+	}
+	return names
+}
+
+func (pw *parquetWriter) write(data []byte) error {
+	n, err := pw.w.Write(data)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *parquetWriter) WriteRow(values []string) error {
+	pw.pending = append(pw.pending, values)
+	if len(pw.pending) >= pw.rowGroupSize {
+		return pw.flushRowGroup()
+	}
+	return nil
+}
+
+// flushRowGroup writes the pending rows as a row group of one page per column
+func (pw *parquetWriter) flushRowGroup() error {
+	if len(pw.pending) == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{numRows: int64(len(pw.pending))}
+	for i := range pw.columns {
+		var page bytes.Buffer
+		for _, row := range pw.pending {
+			binary.Write(&page, binary.LittleEndian, uint32(len(row[i])))
+			page.WriteString(row[i])
+		}
+
+		header := &thriftWriter{}
+		header.i32(1, parquetPageTypeData)
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.structField(5, func() {
+			header.i32(1, int32(len(pw.pending)))
+			header.i32(2, parquetEncodingPlain)
+			header.i32(3, parquetEncodingRLE)
+			header.i32(4, parquetEncodingRLE)
+		})
+		header.stop()
+
+		chunk := parquetColumnChunk{offset: pw.offset, size: int64(header.buf.Len() + page.Len())}
+		if err := pw.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := pw.write(page.Bytes()); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+	}
+
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.numRows += group.numRows
+	pw.pending = nil
+	return nil
+}
+
+// Close writes the last row group and the footer
+func (pw *parquetWriter) Close() error {
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+
+	meta := &thriftWriter{}
+	meta.i32(1, 1)
+	meta.structList(2, len(pw.columns)+1, func(i int) {
+		if i == 0 {
+			meta.binary(4, "schema")
+			meta.i32(5, int32(len(pw.columns)))
+			return
+		}
+		meta.i32(1, parquetTypeByteArray)
+		meta.i32(3, parquetRequired)
+		meta.binary(4, pw.columns[i-1])
+		meta.i32(6, parquetConvertedUTF8)
+	})
+	meta.i64(3, pw.numRows)
+	meta.structList(4, len(pw.rowGroups), func(i int) {
+		group := pw.rowGroups[i]
+		var total int64
+
+		meta.structList(1, len(group.chunks), func(j int) {
+			chunk := group.chunks[j]
+			total += chunk.size
+
+			meta.i64(2, chunk.offset)
+			meta.structField(3, func() {
+				meta.i32(1, parquetTypeByteArray)
+				meta.listI32(2, parquetEncodingPlain, parquetEncodingRLE)
+				meta.listBinary(3, pw.columns[j])
+				meta.i32(4, parquetUncompressed)
+				meta.i64(5, group.numRows)
+				meta.i64(6, chunk.size)
+				meta.i64(7, chunk.size)
+				meta.i64(9, chunk.offset)
+			})
+		})
+		meta.i64(2, total)
+		meta.i64(3, group.numRows)
+	})
+	meta.binary(6, "gochimp3")
+	meta.stop()
+
+	if err := pw.write(meta.buf.Bytes()); err != nil {
+		return err
+	}
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(meta.buf.Len()))
+	if err := pw.write(length); err != nil {
+		return err
+	}
+	return pw.write([]byte(parquetMagic))
+}
+
+// ------------------------------------------------------------------------------------------------
+// Thrift compact protocol
+// ------------------------------------------------------------------------------------------------
+
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the structs of the Parquet metadata with the Thrift
+// compact protocol. Fields must be written in increasing id order.
+type thriftWriter struct {
+	buf       bytes.Buffer
+	lastField int16
+}
+
+func (t *thriftWriter) uvarint(v uint64) {
+	var data [binary.MaxVarintLen64]byte
+	t.buf.Write(data[:binary.PutUvarint(data[:], v)])
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	if delta := id - t.lastField; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.varint(int64(id))
+	}
+	t.lastField = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.uvarint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
+
+// structField writes a nested struct, fields inside body are numbered from 0
+func (t *thriftWriter) structField(id int16, body func()) {
+	t.fieldHeader(id, thriftStruct)
+	t.structBody(body)
+}
+
+func (t *thriftWriter) structBody(body func()) {
+	last := t.lastField
+	t.lastField = 0
+	body()
+	t.stop()
+	t.lastField = last
+}
+
+func (t *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.uvarint(uint64(size))
+	}
+}
+
+// structList writes a list of size structs, element writes the fields of
+// each one.
+func (t *thriftWriter) structList(id int16, size int, element func(int)) {
+	t.listHeader(id, thriftStruct, size)
+	for i := 0; i < size; i++ {
+		t.structBody(func() { element(i) })
+	}
+}
+
+func (t *thriftWriter) listI32(id int16, values ...int32) {
+	t.listHeader(id, thriftI32, len(values))
+	for _, v := range values {
+		t.varint(int64(v))
+	}
+}
+
+func (t *thriftWriter) listBinary(id int16, values ...string) {
+	t.listHeader(id, thriftBinary, len(values))
+	for _, v := range values {
+		t.uvarint(uint64(len(v)))
+		t.buf.WriteString(v)
+	}
+}
@@ -0,0 +1,154 @@
+package gochimp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// thriftReader decodes the Thrift compact structs written by thriftWriter,
+// fields are keyed by id.
+type thriftReader struct {
+	r *bytes.Reader
+}
+
+func (t *thriftReader) uvarint() uint64 {
+	v, _ := binary.ReadUvarint(t.r)
+	return v
+}
+
+func (t *thriftReader) varint() int64 {
+	v := t.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (t *thriftReader) value(fieldType byte) interface{} {
+	switch fieldType {
+	case thriftI32, thriftI64:
+		return t.varint()
+	case thriftBinary:
+		data := make([]byte, t.uvarint())
+		io.ReadFull(t.r, data)
+		return string(data)
+	case thriftList:
+		header, _ := t.r.ReadByte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(t.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = t.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return t.structValue()
+	}
+	return nil
+}
+
+func (t *thriftReader) structValue() map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var last int16
+	for {
+		header, err := t.r.ReadByte()
+		if err != nil || header == 0 {
+			return fields
+		}
+
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(t.varint())
+		}
+		fields[id] = t.value(header & 0x0f)
+		last = id
+	}
+}
+
+// readParquet decodes the column names and rows of a file written by
+// parquetWriter
+func readParquet(t *testing.T, data []byte) ([]string, [][]string) {
+	assert.Equal(t, parquetMagic, string(data[:4]))
+	assert.Equal(t, parquetMagic, string(data[len(data)-4:]))
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := (&thriftReader{bytes.NewReader(data[len(data)-8-footer : len(data)-8])}).structValue()
+
+	var names []string
+	schema := meta[2].([]interface{})
+	assert.Equal(t, int64(len(schema)-1), schema[0].(map[int16]interface{})[5])
+	for _, element := range schema[1:] {
+		element := element.(map[int16]interface{})
+		assert.Equal(t, int64(parquetTypeByteArray), element[1])
+		names = append(names, element[4].(string))
+	}
+
+	var rows [][]string
+	for _, group := range meta[4].([]interface{}) {
+		group := group.(map[int16]interface{})
+		numRows := int(group[3].(int64))
+		groupRows := make([][]string, numRows)
+
+		for j, column := range group[1].([]interface{}) {
+			chunk := column.(map[int16]interface{})[3].(map[int16]interface{})
+			assert.Equal(t, []interface{}{names[j]}, chunk[3])
+			assert.Equal(t, int64(numRows), chunk[5])
+
+			page := bytes.NewReader(data[chunk[9].(int64):])
+			header := (&thriftReader{page}).structValue()
+			assert.Equal(t, int64(numRows), header[5].(map[int16]interface{})[1])
+			values := make([]byte, header[3].(int64))
+			_, err := io.ReadFull(page, values)
+			fatalIf(t, err)
+
+			for i := range groupRows {
+				size := binary.LittleEndian.Uint32(values)
+				groupRows[i] = append(groupRows[i], string(values[4:4+size]))
+				values = values[4+size:]
+			}
+			assert.Empty(t, values)
+		}
+		rows = append(rows, groupRows...)
+	}
+	assert.Equal(t, meta[3], int64(len(rows)))
+	return names, rows
+}
+
+var (
+	parquetTestColumns = []string{"email_address", "merge_fields.FNAME", "interests.Topics > Rust", "merge_fields_FNAME"}
+	parquetTestRows    = [][]string{
+		{"ada@example.com", "Ada", "true", ""},
+		{"bob@example.com", "", "", "x"},
+		{"cy@example.com", "Cy", "true", "é"},
+	}
+)
+
+// writeParquetTestFile writes the test rows in row groups of 2
+func writeParquetTestFile(t *testing.T) []byte {
+	buf := new(bytes.Buffer)
+	writer, err := newParquetWriter(buf, parquetTestColumns, 2)
+	fatalIf(t, err)
+	for _, row := range parquetTestRows {
+		fatalIf(t, writer.WriteRow(row))
+	}
+	fatalIf(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestParquetWriterRoundTrip(t *testing.T) {
+	names, decoded := readParquet(t, writeParquetTestFile(t))
+	assert.Equal(t, []string{"email_address", "merge_fields_FNAME", "interests_Topics_Rust", "merge_fields_FNAME_2"}, names)
+	assert.Equal(t, parquetTestRows, decoded)
+}
+
+// testdata/members.parquet was read back with parquet-go v0.32.0 and Apache
+// Arrow Go v18.8.0, both returned the schema, the column chunk metadata and
+// the rows above. The writer must keep producing the same bytes.
+func TestParquetWriterGolden(t *testing.T) {
+	golden, err := ioutil.ReadFile("testdata/members.parquet")
+	fatalIf(t, err)
+	assert.Equal(t, golden, writeParquetTestFile(t))
+}
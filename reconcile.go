@@ -0,0 +1,245 @@
+package gochimp3
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListReconciler compares the members of several lists by email address
+type ListReconciler struct {
+	api *API
+
+	// ListIDs are the lists compared, every list of the account when empty
+	ListIDs []string
+
+	// MergeTags are the merge fields compared, every merge field the lists
+	// have in common when empty.
+	MergeTags []string
+}
+
+func (api *API) NewListReconciler(listIDs ...string) *ListReconciler {
+	return &ListReconciler{api: api, ListIDs: listIDs}
+}
+
+// ReconcileMember is an email address on one of the lists
+type ReconcileMember struct {
+	ListID      string      `json:"list_id"`
+	ListName    string      `json:"list_name"`
+	MemberID    string      `json:"member_id"`
+	Status      string      `json:"status"`
+	MergeFields MergeFields `json:"merge_fields,omitempty"`
+}
+
+// MergeFieldConflict is a merge field with different values on different
+// lists, Values is keyed by list ID.
+type MergeFieldConflict struct {
+	Tag    string            `json:"tag"`
+	Values map[string]string `json:"values"`
+}
+
+// ReconcileEntry is an email address found on more than one list
+type ReconcileEntry struct {
+	EmailAddress        string               `json:"email_address"`
+	Members             []ReconcileMember    `json:"members"`
+	StatusConflict      bool                 `json:"status_conflict"`
+	MergeFieldConflicts []MergeFieldConflict `json:"merge_field_conflicts,omitempty"`
+}
+
+// Unsubscribed checks if the email address unsubscribed from one of the lists
+func (entry *ReconcileEntry) Unsubscribed() bool {
+	for _, member := range entry.Members {
+		if member.Status == MEMBER_STATUS_UNSUBSCRIBED {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconcileReport lists the email addresses on several lists, sorted
+type ReconcileReport struct {
+	ListIDs             []string         `json:"list_ids"`
+	Members             int              `json:"members"`
+	UniqueEmails        int              `json:"unique_emails"`
+	StatusConflicts     int              `json:"status_conflicts"`
+	MergeFieldConflicts int              `json:"merge_field_conflicts"`
+	Duplicates          []ReconcileEntry `json:"duplicates"`
+}
+
+// Reconcile reads every member of the lists, keeping only their status and
+// merge fields, and reports the email addresses on several lists.
+func (reconciler *ListReconciler) Reconcile() (*ReconcileReport, error) {
+	lists, err := reconciler.lists()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconcileReport{Duplicates: []ReconcileEntry{}}
+	byEmail := make(map[string][]ReconcileMember)
+
+	params := &MemberQueryParams{}
+	params.Fields = []string{
+		"total_items",
+		"members.id",
+		"members.email_address",
+		"members.status",
+		"members.merge_fields",
+	}
+
+	for i := range lists {
+		list := &lists[i]
+		report.ListIDs = append(report.ListIDs, list.ID)
+
+		err := list.EachMember(params, func(member *Member) error {
+			report.Members++
+			email := strings.ToLower(strings.TrimSpace(member.EmailAddress))
+			byEmail[email] = append(byEmail[email], ReconcileMember{
+				ListID:      list.ID,
+				ListName:    list.Name,
+				MemberID:    member.ID,
+				Status:      member.Status,
+				MergeFields: member.MergeFields,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report.UniqueEmails = len(byEmail)
+	for email, members := range byEmail {
+		if len(members) < 2 {
+			continue
+		}
+
+		entry := ReconcileEntry{
+			EmailAddress:        email,
+			Members:             members,
+			StatusConflict:      statusConflict(members),
+			MergeFieldConflicts: reconciler.mergeFieldConflicts(members),
+		}
+		if entry.StatusConflict {
+			report.StatusConflicts++
+		}
+		if len(entry.MergeFieldConflicts) > 0 {
+			report.MergeFieldConflicts++
+		}
+		report.Duplicates = append(report.Duplicates, entry)
+	}
+
+	sort.Slice(report.Duplicates, func(i, j int) bool {
+		return report.Duplicates[i].EmailAddress < report.Duplicates[j].EmailAddress
+	})
+	return report, nil
+}
+
+func (reconciler *ListReconciler) lists() ([]ListResponse, error) {
+	if len(reconciler.ListIDs) == 0 {
+		return reconciler.api.getAllLists(&ListQueryParams{})
+	}
+
+	lists := make([]ListResponse, len(reconciler.ListIDs))
+	for i, id := range reconciler.ListIDs {
+		list, err := reconciler.api.GetList(id, nil)
+		if err != nil {
+			return nil, err
+		}
+		lists[i] = *list
+	}
+	return lists, nil
+}
+
+// statusConflict checks if the address is subscribed to a list and
+// unsubscribed from another one
+func statusConflict(members []ReconcileMember) bool {
+	subscribed, unsubscribed := false, false
+	for _, member := range members {
+		switch member.Status {
+		case MEMBER_STATUS_SUBSCRIBED:
+			subscribed = true
+		case MEMBER_STATUS_UNSUBSCRIBED:
+			unsubscribed = true
+		}
+	}
+	return subscribed && unsubscribed
+}
+
+// mergeFieldConflicts compares the merge fields set on more than one list,
+// empty values are not conflicts.
+func (reconciler *ListReconciler) mergeFieldConflicts(members []ReconcileMember) []MergeFieldConflict {
+	values := make(map[string]map[string]string)
+	for _, member := range members {
+		for tag, value := range member.MergeFields {
+			if len(reconciler.MergeTags) > 0 && !containsString(reconciler.MergeTags, tag) {
+				continue
+			}
+			formatted := strings.TrimSpace(formatMergeFieldValue(value))
+			if formatted == "" {
+				continue
+			}
+			if values[tag] == nil {
+				values[tag] = make(map[string]string)
+			}
+			values[tag][member.ListID] = formatted
+		}
+	}
+
+	var conflicts []MergeFieldConflict
+	for tag, byList := range values {
+		distinct := make(map[string]bool)
+		for _, value := range byList {
+			distinct[value] = true
+		}
+		if len(distinct) > 1 {
+			conflicts = append(conflicts, MergeFieldConflict{Tag: tag, Values: byList})
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Tag < conflicts[j].Tag
+	})
+	return conflicts
+}
+
+// ReconcileChange is an unsubscribe propagated to a list
+type ReconcileChange struct {
+	EmailAddress string `json:"email_address"`
+	ListID       string `json:"list_id"`
+	MemberID     string `json:"member_id"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ApplyUnsubscribes unsubscribes the email addresses of the report from
+// every list they are still subscribed to when they unsubscribed from one of
+// the lists. With dryRun the changes are only listed.
+func (reconciler *ListReconciler) ApplyUnsubscribes(report *ReconcileReport, dryRun bool) ([]ReconcileChange, error) {
+	changes := []ReconcileChange{}
+	for _, entry := range report.Duplicates {
+		if !entry.Unsubscribed() {
+			continue
+		}
+
+		for _, member := range entry.Members {
+			if member.Status != MEMBER_STATUS_SUBSCRIBED {
+				continue
+			}
+
+			change := ReconcileChange{EmailAddress: entry.EmailAddress, ListID: member.ListID, MemberID: member.MemberID}
+			if !dryRun {
+				list := reconciler.api.NewListResponse(member.ListID)
+				if err := list.unsubscribeMember(member.MemberID); err != nil {
+					change.Error = err.Error()
+				}
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	for _, change := range changes {
+		if change.Error != "" {
+			return changes, fmt.Errorf("Could not unsubscribe %s from list %s: %s", change.EmailAddress, change.ListID, change.Error)
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,88 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListReconciler(t *testing.T) {
+	member := func(email, status string, fields MergeFields) Member {
+		m := Member{ID: SubscriberHash(email)}
+		m.EmailAddress = email
+		m.Status = status
+		m.MergeFields = fields
+		return m
+	}
+	members := map[string][]Member{
+		"list1": {
+			member("ada@example.com", MEMBER_STATUS_SUBSCRIBED, MergeFields{"FNAME": "Ada", "LNAME": ""}),
+			member("bob@example.com", MEMBER_STATUS_UNSUBSCRIBED, MergeFields{"FNAME": "Bob"}),
+			member("cy@example.com", MEMBER_STATUS_SUBSCRIBED, nil),
+		},
+		"list2": {
+			member("Ada@Example.com", MEMBER_STATUS_SUBSCRIBED, MergeFields{"FNAME": "Adele", "LNAME": "Lovelace"}),
+			member("bob@example.com", MEMBER_STATUS_SUBSCRIBED, MergeFields{"FNAME": "Bob"}),
+		},
+	}
+	var unsubscribed []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists", func(w http.ResponseWriter, r *http.Request) {
+		lists := &ListOfLists{Lists: []ListResponse{{ID: "list1"}, {ID: "list2"}}}
+		lists.Lists[0].Name = "Shop"
+		lists.Lists[1].Name = "Blog"
+		lists.TotalItems = 2
+		writeJSON(w, lists)
+	})
+	for id := range members {
+		id := id
+		mux.HandleFunc("/lists/"+id+"/members", func(w http.ResponseWriter, r *http.Request) {
+			assert.Contains(t, r.URL.Query().Get("fields"), "members.merge_fields")
+			page := &ListOfMembers{Members: members[id]}
+			page.TotalItems = len(members[id])
+			writeJSON(w, page)
+		})
+	}
+	mux.HandleFunc("/lists/list2/members/"+SubscriberHash("bob@example.com"), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		body := map[string]string{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"status": MEMBER_STATUS_UNSUBSCRIBED}, body)
+		unsubscribed = append(unsubscribed, "list2")
+		writeJSON(w, body)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	reconciler := api.NewListReconciler()
+	report, err := reconciler.Reconcile()
+	fatalIf(t, err)
+
+	assert.Equal(t, 5, report.Members)
+	assert.Equal(t, 3, report.UniqueEmails)
+	assert.Equal(t, 1, report.StatusConflicts)
+	assert.Equal(t, 1, report.MergeFieldConflicts)
+	if assert.Len(t, report.Duplicates, 2) {
+		ada := report.Duplicates[0]
+		assert.Equal(t, "ada@example.com", ada.EmailAddress)
+		assert.False(t, ada.StatusConflict)
+		assert.Equal(t, []MergeFieldConflict{{Tag: "FNAME", Values: map[string]string{"list1": "Ada", "list2": "Adele"}}}, ada.MergeFieldConflicts)
+
+		bob := report.Duplicates[1]
+		assert.True(t, bob.StatusConflict)
+		assert.Empty(t, bob.MergeFieldConflicts)
+	}
+
+	changes, err := reconciler.ApplyUnsubscribes(report, true)
+	fatalIf(t, err)
+	assert.Equal(t, []ReconcileChange{{EmailAddress: "bob@example.com", ListID: "list2", MemberID: SubscriberHash("bob@example.com")}}, changes)
+	assert.Empty(t, unsubscribed)
+
+	_, err = reconciler.ApplyUnsubscribes(report, false)
+	fatalIf(t, err)
+	assert.Equal(t, []string{"list2"}, unsubscribed)
+}
@@ -0,0 +1,425 @@
+package gochimp3
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	CONDITION_TYPE_TEXT_MERGE          = "TextMerge"
+	CONDITION_TYPE_SELECT_MERGE        = "SelectMerge"
+	CONDITION_TYPE_DATE                = "Date"
+	CONDITION_TYPE_ECOMM_PURCHASED     = "EcommPurchased"
+	CONDITION_TYPE_ECOMM_SPENT         = "EcommSpent"
+	CONDITION_TYPE_ECOMM_PRODUCT       = "EcommProd"
+	CONDITION_TYPE_CAMPAIGN_ACTIVITY   = "Aim"
+	CONDITION_TYPE_STATIC_SEGMENT      = "StaticSegment"
+	CONDITION_TYPE_MEMBER_RATING       = "MemberRating"
+	CONDITION_TYPE_IPGEO_COUNTRY_STATE = "IPGeoCountryState"
+	CONDITION_TYPE_EMAIL_CLIENT        = "EmailClient"
+	CONDITION_TYPE_AUTOMATION          = "Automation"
+
+	CONDITION_FIELD_INTERESTS_PREFIX  = "interests-"
+	CONDITION_FIELD_SIGNUP_DATE       = "timestamp_opt"
+	CONDITION_FIELD_INFO_CHANGED      = "info_changed"
+	CONDITION_FIELD_ECOMM_DATE        = "ecomm_date"
+	CONDITION_FIELD_ECOMM_PURCHASED   = "ecomm_purchased"
+	CONDITION_FIELD_ECOMM_SPENT_ONE   = "ecomm_spent_one"
+	CONDITION_FIELD_ECOMM_SPENT_ALL   = "ecomm_spent_all"
+	CONDITION_FIELD_ECOMM_PRODUCT     = "ecomm_prod"
+	CONDITION_FIELD_CAMPAIGN_ACTIVITY = "aim"
+	CONDITION_FIELD_STATIC_SEGMENT    = "static_segment"
+	CONDITION_FIELD_MEMBER_RATING     = "rating"
+	CONDITION_FIELD_IPGEO             = "ipgeo"
+	CONDITION_FIELD_EMAIL_CLIENT      = "email_client"
+	CONDITION_FIELD_AUTOMATION        = "automation"
+
+	CONDITION_OP_IS            = "is"
+	CONDITION_OP_NOT           = "not"
+	CONDITION_OP_TEXT_CONTAINS = "contains"
+	CONDITION_OP_NOT_CONTAIN   = "notcontain"
+	CONDITION_OP_STARTS        = "starts"
+	CONDITION_OP_ENDS          = "ends"
+	CONDITION_OP_GREATER       = "greater"
+	CONDITION_OP_LESS          = "less"
+	CONDITION_OP_BLANK         = "blank"
+	CONDITION_OP_BLANK_NOT     = "blank_not"
+
+	CONDITION_OP_INTEREST_CONTAINS     = CONDITION_OP_CONTAINS
+	CONDITION_OP_INTEREST_CONTAINS_ALL = "interestcontainsall"
+	CONDITION_OP_INTEREST_NOT_CONTAINS = "interestnotcontains"
+
+	CONDITION_OP_MEMBER     = "member"
+	CONDITION_OP_NOT_MEMBER = "notmember"
+
+	CONDITION_OP_OPEN     = "open"
+	CONDITION_OP_CLICK    = "click"
+	CONDITION_OP_SENT     = "sent"
+	CONDITION_OP_NO_OPEN  = "noopen"
+	CONDITION_OP_NO_CLICK = "noclick"
+	CONDITION_OP_NO_SENT  = "nosent"
+
+	CONDITION_OP_STATIC_IS  = "static_is"
+	CONDITION_OP_STATIC_NOT = "static_not"
+
+	CONDITION_OP_IPGEO_COUNTRY     = "ipgeocountry"
+	CONDITION_OP_IPGEO_NOT_COUNTRY = "ipgeonotcountry"
+	CONDITION_OP_IPGEO_STATE       = "ipgeostate"
+	CONDITION_OP_IPGEO_NOT_STATE   = "ipgeonotstate"
+
+	CONDITION_OP_CLIENT_IS  = "client_is"
+	CONDITION_OP_CLIENT_NOT = "client_not"
+
+	CONDITION_OP_STARTED       = "started"
+	CONDITION_OP_COMPLETED     = "completed"
+	CONDITION_OP_NOT_STARTED   = "not_started"
+	CONDITION_OP_NOT_COMPLETED = "not_completed"
+
+	// CONDITION_VALUE_ANY_CAMPAIGN matches the activity on any campaign
+	CONDITION_VALUE_ANY_CAMPAIGN = "any"
+
+	// conditionDateLayout is the layout of the Extra of date conditions
+	conditionDateLayout = "2006-01-02"
+)
+
+// ------------------------------------------------------------------------------------------------
+// Conditions
+// ------------------------------------------------------------------------------------------------
+
+// TextMergeCondition matches a text merge field, the value is ignored by the
+// blank operators.
+func TextMergeCondition(tag, op, value string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_TEXT_MERGE, Field: tag, OP: op, Value: value}
+}
+
+// SelectMergeCondition matches a dropdown or radio merge field
+func SelectMergeCondition(tag, op, value string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_SELECT_MERGE, Field: tag, OP: op, Value: value}
+}
+
+// InterestCondition matches the interests of a category, see
+// InterestCatalog to find interest IDs by name.
+func InterestCondition(categoryID, op string, interestIDs ...string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_INTERESTS, Field: CONDITION_FIELD_INTERESTS_PREFIX + categoryID, OP: op, Value: interestIDs}
+}
+
+// DateCondition compares a date field, one of CONDITION_FIELD_SIGNUP_DATE,
+// CONDITION_FIELD_INFO_CHANGED or CONDITION_FIELD_ECOMM_DATE, with a day.
+func DateCondition(field, op string, date time.Time) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_DATE, Field: field, OP: op, Value: "date", Extra: date.Format(conditionDateLayout)}
+}
+
+// EcommPurchasedCondition matches the members who purchased anything, with
+// CONDITION_OP_MEMBER, or nothing with CONDITION_OP_NOT_MEMBER.
+func EcommPurchasedCondition(op string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_ECOMM_PURCHASED, Field: CONDITION_FIELD_ECOMM_PURCHASED, OP: op}
+}
+
+// EcommSpentCondition compares the amount spent in one order, with
+// CONDITION_FIELD_ECOMM_SPENT_ONE, or in total with
+// CONDITION_FIELD_ECOMM_SPENT_ALL.
+func EcommSpentCondition(field, op string, amount int) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_ECOMM_SPENT, Field: field, OP: op, Value: amount}
+}
+
+// EcommProductCondition matches the names of the products purchased
+func EcommProductCondition(op, product string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_ECOMM_PRODUCT, Field: CONDITION_FIELD_ECOMM_PRODUCT, OP: op, Value: product}
+}
+
+// CampaignActivityCondition matches the members who opened, clicked or were
+// sent a campaign, or CONDITION_VALUE_ANY_CAMPAIGN.
+func CampaignActivityCondition(op, campaignID string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_CAMPAIGN_ACTIVITY, Field: CONDITION_FIELD_CAMPAIGN_ACTIVITY, OP: op, Value: campaignID}
+}
+
+// TagCondition matches the members with or without a tag, tagID is the ID of
+// the static segment of the tag.
+func TagCondition(op string, tagID int) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_STATIC_SEGMENT, Field: CONDITION_FIELD_STATIC_SEGMENT, OP: op, Value: tagID}
+}
+
+// MemberRatingCondition compares the member rating, from 1 to 5
+func MemberRatingCondition(op string, rating int) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_MEMBER_RATING, Field: CONDITION_FIELD_MEMBER_RATING, OP: op, Value: rating}
+}
+
+// LocationCondition matches the country, by its ISO code, or the US state of
+// the members.
+func LocationCondition(op, code string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_IPGEO_COUNTRY_STATE, Field: CONDITION_FIELD_IPGEO, OP: op, Value: code}
+}
+
+// EmailClientCondition matches the email client used by the members
+func EmailClientCondition(op, client string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_EMAIL_CLIENT, Field: CONDITION_FIELD_EMAIL_CLIENT, OP: op, Value: client}
+}
+
+// AutomationCondition matches the members who started or completed an
+// automation workflow.
+func AutomationCondition(op, workflowID string) SegmentConditional {
+	return SegmentConditional{ConditionType: CONDITION_TYPE_AUTOMATION, Field: CONDITION_FIELD_AUTOMATION, OP: op, Value: workflowID}
+}
+
+// ------------------------------------------------------------------------------------------------
+// Validation
+// ------------------------------------------------------------------------------------------------
+
+var mergeTagPattern = regexp.MustCompile(`^[A-Z0-9_]{1,10}$`)
+
+type conditionRule struct {
+	fields []string
+	field  func(string) bool
+	ops    []string
+	value  func(value interface{}) error
+}
+
+var conditionRules = map[string]conditionRule{
+	CONDITION_TYPE_TEXT_MERGE: {
+		field: mergeTagPattern.MatchString,
+		ops:   []string{CONDITION_OP_IS, CONDITION_OP_NOT, CONDITION_OP_TEXT_CONTAINS, CONDITION_OP_NOT_CONTAIN, CONDITION_OP_STARTS, CONDITION_OP_ENDS, CONDITION_OP_GREATER, CONDITION_OP_LESS, CONDITION_OP_BLANK, CONDITION_OP_BLANK_NOT},
+		value: conditionString,
+	},
+	CONDITION_TYPE_SELECT_MERGE: {
+		field: mergeTagPattern.MatchString,
+		ops:   []string{CONDITION_OP_IS, CONDITION_OP_NOT, CONDITION_OP_BLANK, CONDITION_OP_BLANK_NOT},
+		value: conditionString,
+	},
+	CONDITION_TYPE_INTERESTS: {
+		field: func(field string) bool {
+			return strings.HasPrefix(field, CONDITION_FIELD_INTERESTS_PREFIX) && len(field) > len(CONDITION_FIELD_INTERESTS_PREFIX)
+		},
+		ops:   []string{CONDITION_OP_INTEREST_CONTAINS, CONDITION_OP_INTEREST_CONTAINS_ALL, CONDITION_OP_INTEREST_NOT_CONTAINS},
+		value: conditionStrings,
+	},
+	CONDITION_TYPE_DATE: {
+		fields: []string{CONDITION_FIELD_SIGNUP_DATE, CONDITION_FIELD_INFO_CHANGED, CONDITION_FIELD_ECOMM_DATE},
+		ops:    []string{CONDITION_OP_GREATER, CONDITION_OP_LESS, CONDITION_OP_IS, CONDITION_OP_NOT},
+		value:  conditionString,
+	},
+	CONDITION_TYPE_ECOMM_PURCHASED: {
+		fields: []string{CONDITION_FIELD_ECOMM_PURCHASED},
+		ops:    []string{CONDITION_OP_MEMBER, CONDITION_OP_NOT_MEMBER},
+	},
+	CONDITION_TYPE_ECOMM_SPENT: {
+		fields: []string{CONDITION_FIELD_ECOMM_SPENT_ONE, CONDITION_FIELD_ECOMM_SPENT_ALL},
+		ops:    []string{CONDITION_OP_GREATER, CONDITION_OP_LESS},
+		value:  conditionNumber(0, -1),
+	},
+	CONDITION_TYPE_ECOMM_PRODUCT: {
+		fields: []string{CONDITION_FIELD_ECOMM_PRODUCT},
+		ops:    []string{CONDITION_OP_IS, CONDITION_OP_NOT, CONDITION_OP_TEXT_CONTAINS, CONDITION_OP_NOT_CONTAIN, CONDITION_OP_STARTS, CONDITION_OP_ENDS},
+		value:  conditionString,
+	},
+	CONDITION_TYPE_CAMPAIGN_ACTIVITY: {
+		fields: []string{CONDITION_FIELD_CAMPAIGN_ACTIVITY},
+		ops:    []string{CONDITION_OP_OPEN, CONDITION_OP_CLICK, CONDITION_OP_SENT, CONDITION_OP_NO_OPEN, CONDITION_OP_NO_CLICK, CONDITION_OP_NO_SENT},
+		value:  conditionString,
+	},
+	CONDITION_TYPE_STATIC_SEGMENT: {
+		fields: []string{CONDITION_FIELD_STATIC_SEGMENT},
+		ops:    []string{CONDITION_OP_STATIC_IS, CONDITION_OP_STATIC_NOT},
+		value:  conditionNumber(1, -1),
+	},
+	CONDITION_TYPE_MEMBER_RATING: {
+		fields: []string{CONDITION_FIELD_MEMBER_RATING},
+		ops:    []string{CONDITION_OP_IS, CONDITION_OP_NOT, CONDITION_OP_GREATER, CONDITION_OP_LESS},
+		value:  conditionNumber(1, 5),
+	},
+	CONDITION_TYPE_IPGEO_COUNTRY_STATE: {
+		fields: []string{CONDITION_FIELD_IPGEO},
+		ops:    []string{CONDITION_OP_IPGEO_COUNTRY, CONDITION_OP_IPGEO_NOT_COUNTRY, CONDITION_OP_IPGEO_STATE, CONDITION_OP_IPGEO_NOT_STATE},
+		value:  conditionString,
+	},
+	CONDITION_TYPE_EMAIL_CLIENT: {
+		fields: []string{CONDITION_FIELD_EMAIL_CLIENT},
+		ops:    []string{CONDITION_OP_CLIENT_IS, CONDITION_OP_CLIENT_NOT},
+		value:  conditionString,
+	},
+	CONDITION_TYPE_AUTOMATION: {
+		fields: []string{CONDITION_FIELD_AUTOMATION},
+		ops:    []string{CONDITION_OP_STARTED, CONDITION_OP_COMPLETED, CONDITION_OP_NOT_STARTED, CONDITION_OP_NOT_COMPLETED},
+		value:  conditionString,
+	},
+}
+
+// Validate checks the field, operator and value of the condition against its
+// condition type.
+func (condition SegmentConditional) Validate() error {
+	if condition.ConditionType == "" {
+		return errors.New("No condition type provided on condition")
+	}
+
+	rule, ok := conditionRules[condition.ConditionType]
+	if !ok {
+		return fmt.Errorf("Unknown condition type %q", condition.ConditionType)
+	}
+
+	validField := containsString(rule.fields, condition.Field)
+	if rule.field != nil {
+		validField = rule.field(condition.Field)
+	}
+	if !validField {
+		return fmt.Errorf("Invalid field %q for %s condition", condition.Field, condition.ConditionType)
+	}
+
+	if !containsString(rule.ops, condition.OP) {
+		return fmt.Errorf("Invalid operator %q for %s condition, expected one of %s", condition.OP, condition.ConditionType, strings.Join(rule.ops, ", "))
+	}
+
+	if condition.ConditionType == CONDITION_TYPE_DATE {
+		date, _ := condition.Extra.(string)
+		if _, err := time.Parse(conditionDateLayout, date); err != nil {
+			return fmt.Errorf("Invalid date %v for %s condition", condition.Extra, condition.ConditionType)
+		}
+	}
+
+	if rule.value == nil || condition.OP == CONDITION_OP_BLANK || condition.OP == CONDITION_OP_BLANK_NOT {
+		return nil
+	}
+	if err := rule.value(condition.Value); err != nil {
+		return fmt.Errorf("Invalid value for %s condition on %s: %v", condition.ConditionType, condition.Field, err)
+	}
+	return nil
+}
+
+func conditionString(value interface{}) error {
+	if s, ok := value.(string); !ok || s == "" {
+		return errors.New("expected a string")
+	}
+	return nil
+}
+
+func conditionStrings(value interface{}) error {
+	var values []string
+	switch v := value.(type) {
+	case []string:
+		values = v
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return errors.New("expected a list of strings")
+			}
+			values = append(values, s)
+		}
+	default:
+		return errors.New("expected a list of strings")
+	}
+
+	if len(values) == 0 {
+		return errors.New("expected at least one value")
+	}
+	return nil
+}
+
+// conditionNumber checks the value is a whole number between min and max,
+// max is ignored when negative.
+func conditionNumber(min, max int) func(interface{}) error {
+	return func(value interface{}) error {
+		var n float64
+		switch v := value.(type) {
+		case int:
+			n = float64(v)
+		case float64:
+			n = v
+		default:
+			return errors.New("expected a number")
+		}
+
+		if n != float64(int(n)) || n < float64(min) || (max >= 0 && n > float64(max)) {
+			if max < 0 {
+				return fmt.Errorf("expected a whole number of at least %d", min)
+			}
+			return fmt.Errorf("expected a whole number between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// Validate checks the match and every condition of the options
+func (options *SegmentOptions) Validate() error {
+	return validateConditions(options.Match, options.Conditions)
+}
+
+func validateConditions(match string, conditions []SegmentConditional) error {
+	if match != CONDITION_MATCH_ANY && match != CONDITION_MATCH_ALL {
+		return fmt.Errorf("Invalid match %q, expected %s or %s", match, CONDITION_MATCH_ANY, CONDITION_MATCH_ALL)
+	}
+
+	if len(conditions) == 0 {
+		return errors.New("No conditions provided")
+	}
+
+	for i := range conditions {
+		if err := conditions[i].Validate(); err != nil {
+			return fmt.Errorf("condition %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// Builder
+// ------------------------------------------------------------------------------------------------
+
+// SegmentBuilder collects conditions and validates them once, for a saved
+// segment, campaign recipients or an automation:
+//
+//	options, err := NewSegmentBuilder(CONDITION_MATCH_ALL).
+//		Add(TextMergeCondition("FNAME", CONDITION_OP_IS, "Ada")).
+//		Add(MemberRatingCondition(CONDITION_OP_GREATER, 3)).
+//		Options()
+type SegmentBuilder struct {
+	Match      string
+	Conditions []SegmentConditional
+}
+
+// NewSegmentBuilder takes one of CONDITION_MATCH_*
+func NewSegmentBuilder(match string) *SegmentBuilder {
+	return &SegmentBuilder{Match: match}
+}
+
+func (builder *SegmentBuilder) Add(conditions ...SegmentConditional) *SegmentBuilder {
+	builder.Conditions = append(builder.Conditions, conditions...)
+	return builder
+}
+
+func (builder *SegmentBuilder) Validate() error {
+	return validateConditions(builder.Match, builder.Conditions)
+}
+
+// Options returns the options of a SegmentRequest
+func (builder *SegmentBuilder) Options() (*SegmentOptions, error) {
+	if err := builder.Validate(); err != nil {
+		return nil, err
+	}
+	return &SegmentOptions{Match: builder.Match, Conditions: builder.conditions()}, nil
+}
+
+// CampaignOptions returns the segment options of CampaignCreationRecipients
+func (builder *SegmentBuilder) CampaignOptions() (CampaignCreationSegmentOptions, error) {
+	if err := builder.Validate(); err != nil {
+		return CampaignCreationSegmentOptions{}, err
+	}
+	return CampaignCreationSegmentOptions{Match: builder.Match, Conditions: builder.conditions()}, nil
+}
+
+// AutomationOptions returns the segment options of an AutomationRecipient
+func (builder *SegmentBuilder) AutomationOptions() (AutomationOptions, error) {
+	if err := builder.Validate(); err != nil {
+		return AutomationOptions{}, err
+	}
+	return AutomationOptions{Match: builder.Match, Conditions: builder.conditions()}, nil
+}
+
+// conditions copies the conditions so later calls to Add don't change
+// options already returned
+func (builder *SegmentBuilder) conditions() []SegmentConditional {
+	conditions := make([]SegmentConditional, len(builder.Conditions))
+	copy(conditions, builder.Conditions)
+	return conditions
+}
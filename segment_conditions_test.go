@@ -0,0 +1,69 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentBuilder(t *testing.T) {
+	builder := NewSegmentBuilder(CONDITION_MATCH_ALL).
+		Add(TextMergeCondition("FNAME", CONDITION_OP_STARTS, "Ad")).
+		Add(InterestCondition("abc", CONDITION_OP_INTEREST_CONTAINS, "i1", "i2")).
+		Add(DateCondition(CONDITION_FIELD_SIGNUP_DATE, CONDITION_OP_GREATER, time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC))).
+		Add(EcommPurchasedCondition(CONDITION_OP_MEMBER)).
+		Add(MemberRatingCondition(CONDITION_OP_GREATER, 3))
+
+	options, err := builder.Options()
+	fatalIf(t, err)
+	data, err := json.Marshal(options)
+	fatalIf(t, err)
+	assert.JSONEq(t, `{"match": "all", "conditions": [
+		{"condition_type": "TextMerge", "field": "FNAME", "op": "starts", "value": "Ad"},
+		{"condition_type": "Interests", "field": "interests-abc", "op": "interestcontains", "value": ["i1", "i2"]},
+		{"condition_type": "Date", "field": "timestamp_opt", "op": "greater", "value": "date", "extra": "2019-01-02"},
+		{"condition_type": "EcommPurchased", "field": "ecomm_purchased", "op": "member"},
+		{"condition_type": "MemberRating", "field": "rating", "op": "greater", "value": 3}
+	]}`, string(data))
+
+	decoded := SegmentOptions{}
+	fatalIf(t, json.Unmarshal(data, &decoded))
+	assert.NoError(t, decoded.Validate())
+
+	campaign, err := builder.CampaignOptions()
+	fatalIf(t, err)
+	assert.Len(t, campaign.Conditions, 5)
+
+	builder.Add(TagCondition(CONDITION_OP_STATIC_IS, 42))
+	automation, err := builder.AutomationOptions()
+	fatalIf(t, err)
+	assert.Len(t, automation.Conditions, 6)
+	assert.Len(t, options.Conditions, 5)
+
+	invalid := []SegmentConditional{
+		TextMergeCondition("first name", CONDITION_OP_IS, "Ada"),
+		SelectMergeCondition("PLAN", CONDITION_OP_STARTS, "Pro"),
+		InterestCondition("abc", CONDITION_OP_INTEREST_CONTAINS),
+		MemberRatingCondition(CONDITION_OP_IS, 6),
+		EcommSpentCondition("ecomm_spent", CONDITION_OP_GREATER, 10),
+		CampaignActivityCondition(CONDITION_OP_OPEN, ""),
+		{Field: "FNAME", OP: CONDITION_OP_IS, Value: "Ada"},
+		{ConditionType: CONDITION_TYPE_DATE, Field: CONDITION_FIELD_SIGNUP_DATE, OP: CONDITION_OP_LESS, Value: "date", Extra: "yesterday"},
+	}
+	for _, condition := range invalid {
+		assert.Error(t, condition.Validate(), "%+v", condition)
+	}
+
+	assert.NoError(t, TextMergeCondition("FNAME", CONDITION_OP_BLANK, "").Validate())
+	assert.NoError(t, LocationCondition(CONDITION_OP_IPGEO_COUNTRY, "FR").Validate())
+	assert.NoError(t, EmailClientCondition(CONDITION_OP_CLIENT_IS, "Gmail").Validate())
+	assert.NoError(t, AutomationCondition(CONDITION_OP_COMPLETED, "wf1").Validate())
+	assert.NoError(t, EcommProductCondition(CONDITION_OP_TEXT_CONTAINS, "Shoes").Validate())
+
+	_, err = NewSegmentBuilder("some").Add(EcommPurchasedCondition(CONDITION_OP_MEMBER)).Options()
+	assert.Error(t, err)
+	_, err = NewSegmentBuilder(CONDITION_MATCH_ANY).Options()
+	assert.Error(t, err)
+}
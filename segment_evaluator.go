@@ -0,0 +1,153 @@
+package gochimp3
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Matches predicts if a member belongs to a saved segment with the options.
+// Only merge field and tag conditions can be evaluated offline, comparisons
+// ignore case and greater and less compare numbers when both sides are
+// numbers.
+func (options *SegmentOptions) Matches(member *Member) (bool, error) {
+	if err := options.Validate(); err != nil {
+		return false, err
+	}
+
+	return options.matches(member)
+}
+
+func (options *SegmentOptions) matches(member *Member) (bool, error) {
+	for _, condition := range options.Conditions {
+		matched, err := evaluateCondition(condition, member)
+		if err != nil {
+			return false, err
+		}
+
+		if matched && options.Match == CONDITION_MATCH_ANY {
+			return true, nil
+		}
+		if !matched && options.Match == CONDITION_MATCH_ALL {
+			return false, nil
+		}
+	}
+	return options.Match == CONDITION_MATCH_ALL, nil
+}
+
+// FilterMembers returns the members matching the options, see Matches
+func (options *SegmentOptions) FilterMembers(members []Member) ([]Member, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	matching := []Member{}
+	for i := range members {
+		matched, err := options.matches(&members[i])
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matching = append(matching, members[i])
+		}
+	}
+	return matching, nil
+}
+
+// PredictMembers returns the members matching the conditions of a saved
+// segment, e.g. to test them on a fetched list before updating the segment.
+func (segment *Segment) PredictMembers(members []Member) ([]Member, error) {
+	if segment.Options == nil {
+		return nil, errors.New("No conditions provided on segment")
+	}
+
+	return segment.Options.FilterMembers(members)
+}
+
+func evaluateCondition(condition SegmentConditional, member *Member) (bool, error) {
+	switch condition.ConditionType {
+	case CONDITION_TYPE_TEXT_MERGE, CONDITION_TYPE_SELECT_MERGE:
+		return evaluateMergeCondition(condition, member), nil
+
+	case CONDITION_TYPE_STATIC_SEGMENT:
+		id, _ := conditionInt(condition.Value)
+		tagged := false
+		for _, tag := range member.Tags {
+			if tag.ID == id {
+				tagged = true
+				break
+			}
+		}
+		return tagged == (condition.OP == CONDITION_OP_STATIC_IS), nil
+
+	default:
+		return false, fmt.Errorf("%s conditions can not be evaluated offline", condition.ConditionType)
+	}
+}
+
+func evaluateMergeCondition(condition SegmentConditional, member *Member) bool {
+	var actual string
+	if condition.Field == "EMAIL" {
+		actual = member.EmailAddress
+	} else {
+		actual = formatMergeFieldValue(member.MergeFields[condition.Field])
+	}
+	actual = strings.ToLower(strings.TrimSpace(actual))
+
+	expected, _ := condition.Value.(string)
+	expected = strings.ToLower(strings.TrimSpace(expected))
+
+	switch condition.OP {
+	case CONDITION_OP_IS:
+		return actual == expected
+	case CONDITION_OP_NOT:
+		return actual != expected
+	case CONDITION_OP_TEXT_CONTAINS:
+		return strings.Contains(actual, expected)
+	case CONDITION_OP_NOT_CONTAIN:
+		return !strings.Contains(actual, expected)
+	case CONDITION_OP_STARTS:
+		return strings.HasPrefix(actual, expected)
+	case CONDITION_OP_ENDS:
+		return strings.HasSuffix(actual, expected)
+	case CONDITION_OP_GREATER:
+		return compareConditionValues(actual, expected) > 0
+	case CONDITION_OP_LESS:
+		return actual != "" && compareConditionValues(actual, expected) < 0
+	case CONDITION_OP_BLANK:
+		return actual == ""
+	case CONDITION_OP_BLANK_NOT:
+		return actual != ""
+	}
+	return false
+}
+
+// compareConditionValues compares numbers numerically and anything else as
+// strings
+func compareConditionValues(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// conditionInt returns a number condition value, which is a float64 once
+// decoded from JSON
+func conditionInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
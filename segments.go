@@ -1,12 +1,29 @@
 package gochimp3
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
 
 const (
 	segments_path       = "/lists/%s/segments"
-	single_segment_path = segments_path + "/%s"
+	single_segment_path = segments_path + "/%d"
+
+	segment_members_path       = single_segment_path + "/members"
+	single_segment_member_path = segment_members_path + "/%s"
+
+	SEGMENT_TYPE_SAVED  SegmentType = "saved"
+	SEGMENT_TYPE_STATIC SegmentType = "static"
+	SEGMENT_TYPE_FUZZY  SegmentType = "fuzzy"
 )
 
+// SegmentType is one of SEGMENT_TYPE_*. Tags are static segments, saved
+// segments have conditions and fuzzy segments are managed by Mailchimp.
+type SegmentType string
+
 type ListOfSegments struct {
 	baseList
 
@@ -23,14 +40,27 @@ type SegmentRequest struct {
 type Segment struct {
 	SegmentRequest
 
-	ID          string    `json:"id"`
-	MemberCount int    `json:"member_count"`
-	Type        string `json:"type"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
-	ListID      string `json:"list_id"`
+	ID          int         `json:"id"`
+	MemberCount int         `json:"member_count"`
+	Type        SegmentType `json:"type"`
+	CreatedAt   string      `json:"created_at"`
+	UpdatedAt   string      `json:"updated_at"`
+	ListID      string      `json:"list_id"`
 
 	withLinks
+	api *API
+}
+
+func (segment *Segment) CanMakeRequest() error {
+	if segment.ListID == "" {
+		return errors.New("No ListID provided on segment")
+	}
+
+	if segment.ID == 0 {
+		return errors.New("No ID provided on segment")
+	}
+
+	return nil
 }
 
 type SegmentOptions struct {
@@ -68,17 +98,22 @@ type SegmentBatchError struct {
 	Error          string   `json:"error"`
 }
 
-// SegmentConditional represents parameters to filter by
+// SegmentConditional represents parameters to filter by, see
+// segment_conditions.go for constructors of every condition type.
 type SegmentConditional struct {
-	Field string      `json:"field"`
-	OP    string      `json:"op"`
-	Value interface{} `json:"value"`
+	ConditionType string      `json:"condition_type,omitempty"` // one of CONDITION_TYPE_*
+	Field         string      `json:"field"`
+	OP            string      `json:"op"`
+	Value         interface{} `json:"value,omitempty"`
+
+	// Extra is the date of date conditions
+	Extra interface{} `json:"extra,omitempty"`
 }
 
 type SegmentQueryParams struct {
 	ExtendedQueryParams
 
-	Type            string
+	Type            SegmentType
 	SinceCreatedAt  string
 	BeforeCreatedAt string
 	SinceUpdatedAt  string
@@ -88,7 +123,7 @@ type SegmentQueryParams struct {
 func (q *SegmentQueryParams) Params() map[string]string {
 	m := q.ExtendedQueryParams.Params()
 
-	m["type"] = q.Type
+	m["type"] = string(q.Type)
 	m["since_created_at"] = q.SinceCreatedAt
 	m["since_updated_at"] = q.SinceUpdatedAt
 	m["before_created_at"] = q.BeforeCreatedAt
@@ -105,16 +140,26 @@ func (list *ListResponse) GetSegments(params *SegmentQueryParams) (*ListOfSegmen
 	endpoint := fmt.Sprintf(segments_path, list.ID)
 	response := new(ListOfSegments)
 
-	return response, list.api.Request("GET", endpoint, params, nil, response)
+	err := list.api.Request("GET", endpoint, params, nil, response)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range response.Segments {
+		response.Segments[i].api = list.api
+	}
+
+	return response, nil
 }
 
-func (list *ListResponse) GetSegment(id string, params *BasicQueryParams) (*Segment, error) {
+func (list *ListResponse) GetSegment(id int, params *BasicQueryParams) (*Segment, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return nil, err
 	}
 
 	endpoint := fmt.Sprintf(single_segment_path, list.ID, id)
 	response := new(Segment)
+	response.api = list.api
 
 	return response, list.api.Request("GET", endpoint, params, nil, response)
 }
@@ -126,17 +171,19 @@ func (list *ListResponse) CreateSegment(body *SegmentRequest) (*Segment, error)
 
 	endpoint := fmt.Sprintf(segments_path, list.ID)
 	response := new(Segment)
+	response.api = list.api
 
 	return response, list.api.Request("POST", endpoint, nil, &body, response)
 }
 
-func (list *ListResponse) UpdateSegment(id string, body *SegmentRequest) (*Segment, error) {
+func (list *ListResponse) UpdateSegment(id int, body *SegmentRequest) (*Segment, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return nil, err
 	}
 
 	endpoint := fmt.Sprintf(single_segment_path, list.ID, id)
 	response := new(Segment)
+	response.api = list.api
 
 	return response, list.api.Request("PATCH", endpoint, nil, &body, response)
 }
@@ -145,7 +192,7 @@ func (list *ListResponse) UpdateSegment(id string, body *SegmentRequest) (*Segme
 // segment using POST /lists/{list_id}/segments/{segment_id}. NOTE: You MUST
 // check SegmentBatchResponse for errors, as there may be multiple errors (i.e.
 // multiple failures to add/remove), and err may still be nil.
-func (list *ListResponse) BatchModifySegment(id string, body *SegmentBatchRequest) (*SegmentBatchResponse, error) {
+func (list *ListResponse) BatchModifySegment(id int, body *SegmentBatchRequest) (*SegmentBatchResponse, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return nil, err
 	}
@@ -156,7 +203,7 @@ func (list *ListResponse) BatchModifySegment(id string, body *SegmentBatchReques
 	return response, list.api.Request("POST", endpoint, nil, &body, response)
 }
 
-func (list *ListResponse) DeleteSegment(id string) (bool, error) {
+func (list *ListResponse) DeleteSegment(id int) (bool, error) {
 	if err := list.CanMakeRequest(); err != nil {
 		return false, err
 	}
@@ -164,3 +211,193 @@ func (list *ListResponse) DeleteSegment(id string) (bool, error) {
 	endpoint := fmt.Sprintf(single_segment_path, list.ID, id)
 	return list.api.RequestOk("DELETE", endpoint)
 }
+
+// ------------------------------------------------------------------------------------------------
+// Segment Members
+// ------------------------------------------------------------------------------------------------
+
+type SegmentMemberQueryParams struct {
+	ExtendedQueryParams
+
+	IncludeCleaned       bool
+	IncludeTransactional bool
+	IncludeUnsubscribed  bool
+}
+
+func (q *SegmentMemberQueryParams) Params() map[string]string {
+	m := q.ExtendedQueryParams.Params()
+	m["include_cleaned"] = strconv.FormatBool(q.IncludeCleaned)
+	m["include_transactional"] = strconv.FormatBool(q.IncludeTransactional)
+	m["include_unsubscribed"] = strconv.FormatBool(q.IncludeUnsubscribed)
+	return m
+}
+
+func (segment *Segment) GetMembers(params *SegmentMemberQueryParams) (*ListOfMembers, error) {
+	if err := segment.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(segment_members_path, segment.ListID, segment.ID)
+	response := new(ListOfMembers)
+
+	err := segment.api.Request("GET", endpoint, params, nil, response)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range response.Members {
+		response.Members[i].api = segment.api
+		if response.Members[i].ListID == "" {
+			response.Members[i].ListID = segment.ListID
+		}
+	}
+
+	return response, nil
+}
+
+// EachMember pages through the members of the segment matching params, which
+// may be nil, and calls fn with each of them until it returns an error.
+func (segment *Segment) EachMember(params *SegmentMemberQueryParams, fn func(*Member) error) error {
+	query := SegmentMemberQueryParams{}
+	if params != nil {
+		query = *params
+	}
+
+	return pageThrough(&query.ExtendedQueryParams, func() (int, int, error) {
+		page, err := segment.GetMembers(&query)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for i := range page.Members {
+			if err := fn(&page.Members[i]); err != nil {
+				return 0, 0, err
+			}
+		}
+		return len(page.Members), page.TotalItems, nil
+	})
+}
+
+// AddMember adds a list member to a static segment
+func (segment *Segment) AddMember(email string) (*Member, error) {
+	if err := segment.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(segment_members_path, segment.ListID, segment.ID)
+	body := map[string]string{"email_address": email}
+	response := new(Member)
+	response.api = segment.api
+
+	return response, segment.api.Request("POST", endpoint, nil, body, response)
+}
+
+// RemoveMember removes a member from a static segment, the member stays on
+// the list.
+func (segment *Segment) RemoveMember(email string) (bool, error) {
+	if err := segment.CanMakeRequest(); err != nil {
+		return false, err
+	}
+
+	endpoint := fmt.Sprintf(single_segment_member_path, segment.ListID, segment.ID, SubscriberHash(email))
+	return segment.api.RequestOk("DELETE", endpoint)
+}
+
+// ------------------------------------------------------------------------------------------------
+// Static Segment Sync
+// ------------------------------------------------------------------------------------------------
+
+// StaticSegmentSyncResult reports the changes made by SyncStaticSegment
+type StaticSegmentSyncResult struct {
+	SegmentID int `json:"segment_id"`
+
+	// ToAdd and ToRemove are the differences found, sorted
+	ToAdd    []string `json:"to_add"`
+	ToRemove []string `json:"to_remove"`
+
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Batches int `json:"batches"`
+
+	// Errors combines the errors of every batch, a batch which failed as a
+	// whole reports all of its emails with the error of the request.
+	Errors []SegmentBatchError `json:"errors,omitempty"`
+}
+
+// SyncStaticSegment makes emails the exact membership of a static segment.
+// The current members, including unsubscribed and cleaned ones, are compared
+// to emails, ignoring case, and the differences are applied in batches of at
+// most 500 additions and 500 removals. A failed batch does not stop the other
+// ones, check the Errors of the result.
+func (list *ListResponse) SyncStaticSegment(segmentID int, emails []string) (*StaticSegmentSyncResult, error) {
+	segment, err := list.GetSegment(segmentID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if segment.ListID == "" {
+		segment.ListID = list.ID
+	}
+	if segment.Type != "" && segment.Type != SEGMENT_TYPE_STATIC {
+		return nil, fmt.Errorf("Segment %d is not a static segment", segment.ID)
+	}
+
+	desired := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			desired[email] = true
+		}
+	}
+
+	params := &SegmentMemberQueryParams{IncludeCleaned: true, IncludeUnsubscribed: true}
+	params.Fields = []string{"total_items", "members.email_address"}
+
+	result := &StaticSegmentSyncResult{SegmentID: segment.ID, ToAdd: []string{}, ToRemove: []string{}}
+	current := make(map[string]bool)
+	err = segment.EachMember(params, func(member *Member) error {
+		email := strings.ToLower(strings.TrimSpace(member.EmailAddress))
+		current[email] = true
+		if !desired[email] {
+			result.ToRemove = append(result.ToRemove, member.EmailAddress)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for email := range desired {
+		if !current[email] {
+			result.ToAdd = append(result.ToAdd, email)
+		}
+	}
+	sort.Strings(result.ToAdd)
+	sort.Strings(result.ToRemove)
+
+	adds := chunkStrings(result.ToAdd, maxSegmentBatchSize)
+	removes := chunkStrings(result.ToRemove, maxSegmentBatchSize)
+	for i := 0; i < len(adds) || i < len(removes); i++ {
+		body := &SegmentBatchRequest{MembersToAdd: []string{}, MembersToRemove: []string{}}
+		if i < len(adds) {
+			body.MembersToAdd = adds[i]
+		}
+		if i < len(removes) {
+			body.MembersToRemove = removes[i]
+		}
+
+		result.Batches++
+		response, err := list.BatchModifySegment(segmentID, body)
+		if err != nil {
+			result.Errors = append(result.Errors, SegmentBatchError{
+				EmailAddresses: append(append([]string{}, body.MembersToAdd...), body.MembersToRemove...),
+				Error:          err.Error(),
+			})
+			continue
+		}
+
+		result.Added += response.TotalAdded
+		result.Removed += response.TotalRemoved
+		result.Errors = append(result.Errors, response.Errors...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,172 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentMembers(t *testing.T) {
+	var offsets []string
+	var added map[string]string
+	removed := false
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/segments/7", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &Segment{ID: 7, ListID: "list1"})
+	})
+	mux.HandleFunc("/lists/list1/segments/7/members", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			fatalIf(t, json.NewDecoder(r.Body).Decode(&added))
+			writeJSON(w, &Member{ID: SubscriberHash(added["email_address"])})
+			return
+		}
+		assert.Equal(t, "true", r.URL.Query().Get("include_unsubscribed"))
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		page := &ListOfMembers{Members: []Member{{ID: "m" + r.URL.Query().Get("offset")}}}
+		page.TotalItems = 2
+		writeJSON(w, page)
+	})
+	mux.HandleFunc("/lists/list1/segments/7/members/"+SubscriberHash("ada@example.com"), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		removed = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	segment, err := api.NewListResponse("list1").GetSegment(7, nil)
+	fatalIf(t, err)
+
+	params := &SegmentMemberQueryParams{IncludeUnsubscribed: true}
+	params.Count = 1
+	var ids []string
+	fatalIf(t, segment.EachMember(params, func(member *Member) error {
+		assert.Equal(t, "list1", member.ListID)
+		ids = append(ids, member.ID)
+		return nil
+	}))
+	assert.Equal(t, []string{"m0", "m1"}, ids)
+	assert.Equal(t, []string{"0", "1"}, offsets)
+
+	member, err := segment.AddMember("ada@example.com")
+	fatalIf(t, err)
+	assert.Equal(t, map[string]string{"email_address": "ada@example.com"}, added)
+	assert.Equal(t, SubscriberHash("ada@example.com"), member.ID)
+
+	_, err = segment.RemoveMember("Ada@Example.com")
+	fatalIf(t, err)
+	assert.True(t, removed)
+
+	_, err = (&Segment{ID: 7}).GetMembers(nil)
+	assert.Error(t, err)
+}
+
+func TestSegmentPredictMembers(t *testing.T) {
+	member := func(email string, fields MergeFields, tags ...int) Member {
+		m := Member{}
+		m.EmailAddress = email
+		m.MergeFields = fields
+		for _, id := range tags {
+			m.Tags = append(m.Tags, MemberTag{ID: id})
+		}
+		return m
+	}
+	members := []Member{
+		member("ada@example.com", MergeFields{"FNAME": "Ada", "AGE": 36.0}, 1),
+		member("bob@example.org", MergeFields{"FNAME": "Bob", "AGE": 9.0}),
+		member("cy@example.com", MergeFields{"FNAME": ""}, 1, 2),
+	}
+	emails := func(members []Member) []string {
+		var emails []string
+		for _, m := range members {
+			emails = append(emails, m.EmailAddress)
+		}
+		return emails
+	}
+
+	segment := &Segment{}
+	segment.Options, _ = NewSegmentBuilder(CONDITION_MATCH_ALL).
+		Add(TagCondition(CONDITION_OP_STATIC_IS, 1)).
+		Add(TextMergeCondition("FNAME", CONDITION_OP_BLANK_NOT, "")).
+		Options()
+	matching, err := segment.PredictMembers(members)
+	fatalIf(t, err)
+	assert.Equal(t, []string{"ada@example.com"}, emails(matching))
+
+	anyOf := &SegmentOptions{Match: CONDITION_MATCH_ANY, Conditions: []SegmentConditional{
+		TextMergeCondition("AGE", CONDITION_OP_GREATER, "10"),
+		TextMergeCondition("EMAIL", CONDITION_OP_ENDS, ".ORG"),
+		TagCondition(CONDITION_OP_STATIC_NOT, 1),
+	}}
+	matching, err = anyOf.FilterMembers(members)
+	fatalIf(t, err)
+	assert.Equal(t, []string{"ada@example.com", "bob@example.org"}, emails(matching))
+
+	ok, err := (&SegmentOptions{Match: CONDITION_MATCH_ALL, Conditions: []SegmentConditional{TextMergeCondition("FNAME", CONDITION_OP_STARTS, "a")}}).Matches(&members[0])
+	fatalIf(t, err)
+	assert.True(t, ok)
+
+	_, err = (&SegmentOptions{Match: CONDITION_MATCH_ALL, Conditions: []SegmentConditional{MemberRatingCondition(CONDITION_OP_IS, 5)}}).Matches(&members[0])
+	assert.Error(t, err)
+
+	_, err = (&Segment{}).PredictMembers(members)
+	assert.Error(t, err)
+}
+
+func TestSyncStaticSegment(t *testing.T) {
+	var batches []SegmentBatchRequest
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/segments/7", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			writeJSON(w, &Segment{ID: 7, ListID: "list1", Type: "static"})
+			return
+		}
+		body := SegmentBatchRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		batches = append(batches, body)
+		response := &SegmentBatchResponse{TotalAdded: len(body.MembersToAdd), TotalRemoved: len(body.MembersToRemove)}
+		if len(batches) == 2 {
+			response.TotalAdded--
+			response.Errors = []SegmentBatchError{{EmailAddresses: []string{"zed@example.com"}, Error: "not on the list"}}
+		}
+		writeJSON(w, response)
+	})
+	mux.HandleFunc("/lists/list1/segments/7/members", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("include_cleaned"))
+		page := &ListOfMembers{Members: []Member{{}, {}}}
+		page.Members[0].EmailAddress = "Keep@example.com"
+		page.Members[1].EmailAddress = "gone@example.com"
+		page.TotalItems = 2
+		writeJSON(w, page)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	emails := []string{"keep@example.com", "zed@example.com", " "}
+	for i := 0; i < maxSegmentBatchSize; i++ {
+		emails = append(emails, fmt.Sprintf("user%03d@example.com", i))
+	}
+
+	result, err := api.NewListResponse("list1").SyncStaticSegment(7, emails)
+	fatalIf(t, err)
+	assert.Len(t, result.ToAdd, maxSegmentBatchSize+1)
+	assert.Equal(t, []string{"gone@example.com"}, result.ToRemove)
+	assert.Equal(t, 2, result.Batches)
+	assert.Equal(t, maxSegmentBatchSize, result.Added)
+	assert.Equal(t, 1, result.Removed)
+	assert.Equal(t, []SegmentBatchError{{EmailAddresses: []string{"zed@example.com"}, Error: "not on the list"}}, result.Errors)
+
+	if assert.Len(t, batches, 2) {
+		assert.Len(t, batches[0].MembersToAdd, maxSegmentBatchSize)
+		assert.Equal(t, []string{"gone@example.com"}, batches[0].MembersToRemove)
+		assert.Equal(t, []string{"zed@example.com"}, batches[1].MembersToAdd)
+		assert.Equal(t, []string{}, batches[1].MembersToRemove)
+	}
+}
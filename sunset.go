@@ -0,0 +1,322 @@
+package gochimp3
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	SUNSET_ACTION_UNSUBSCRIBE = "unsubscribe"
+	SUNSET_ACTION_ARCHIVE     = "archive"
+
+	defaultSunsetSegmentName = "Re-engagement"
+)
+
+// SunsetPolicy decides when a subscriber is inactive and what happens to it.
+// A subscriber is inactive when it did not open or click for InactiveDays, or
+// in the last InactiveCampaigns campaigns it was sent, counting from its last
+// open or click or from its opt-in. A zero threshold is not checked.
+type SunsetPolicy struct {
+	InactiveDays      int `json:"inactive_days"`
+	InactiveCampaigns int `json:"inactive_campaigns"`
+
+	// GracePeriod is how long an inactive subscriber stays in the
+	// re-engagement segment before Action is applied.
+	GracePeriod time.Duration `json:"grace_period"`
+
+	// Action is SUNSET_ACTION_UNSUBSCRIBE or SUNSET_ACTION_ARCHIVE
+	Action string `json:"action"`
+}
+
+func (policy *SunsetPolicy) validate() error {
+	if policy.InactiveDays <= 0 && policy.InactiveCampaigns <= 0 {
+		return errors.New("A sunset policy needs InactiveDays or InactiveCampaigns")
+	}
+	if policy.Action != SUNSET_ACTION_UNSUBSCRIBE && policy.Action != SUNSET_ACTION_ARCHIVE {
+		return fmt.Errorf("Unknown sunset action %q", policy.Action)
+	}
+	return nil
+}
+
+// Inactive checks the activity feed of a subscriber, with its sends, opens
+// and clicks, against the thresholds. optIn is when the subscriber joined.
+func (policy *SunsetPolicy) Inactive(feed MemberActivityFeed, optIn, now time.Time) bool {
+	since := feed.LastEngagedAt()
+	if optIn.After(since) {
+		since = optIn
+	}
+
+	if policy.InactiveDays > 0 && !since.IsZero() && now.Sub(since) >= time.Duration(policy.InactiveDays)*24*time.Hour {
+		return true
+	}
+	if policy.InactiveCampaigns > 0 {
+		sent := feed.Filter(MEMBER_ACTIVITY_SENT).Between(since, time.Time{})
+		return len(sent) >= policy.InactiveCampaigns
+	}
+	return false
+}
+
+// SunsetEnrollment is a subscriber in the re-engagement segment
+type SunsetEnrollment struct {
+	EmailAddress  string    `json:"email_address"`
+	EnrolledAt    time.Time `json:"enrolled_at"`
+	LastEngagedAt time.Time `json:"last_engaged_at"`
+}
+
+// SunsetState is persisted between runs, Enrolled is keyed by member ID
+type SunsetState struct {
+	ListID    string                       `json:"list_id"`
+	SegmentID int                          `json:"segment_id"`
+	Enrolled  map[string]*SunsetEnrollment `json:"enrolled"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+}
+
+// SunsetFailure is a subscriber a step failed for
+type SunsetFailure struct {
+	EmailAddress string `json:"email_address"`
+	Step         string `json:"step"`
+	Error        string `json:"error"`
+}
+
+// SunsetReport lists the email addresses handled by a run. With DryRun
+// nothing was changed and the lists are what a real run would do.
+type SunsetReport struct {
+	DryRun    bool            `json:"dry_run"`
+	Evaluated int             `json:"evaluated"`
+	Enrolled  []string        `json:"enrolled"`
+	Reengaged []string        `json:"reengaged"`
+	Sunset    []string        `json:"sunset"`
+	Failures  []SunsetFailure `json:"failures,omitempty"`
+}
+
+// SunsetRunner applies a SunsetPolicy to the subscribers of a list. It is
+// meant to run regularly, e.g. daily, over the weeks of the grace period:
+// its state is kept in the JSON file at StatePath.
+type SunsetRunner struct {
+	List   *ListResponse
+	Policy SunsetPolicy
+
+	// SegmentName is the name of the static re-engagement segment, created
+	// on the first run.
+	SegmentName string
+	StatePath   string
+	DryRun      bool
+
+	// Now returns the current time, it can be replaced in tests
+	Now func() time.Time
+}
+
+func (list *ListResponse) NewSunsetRunner(policy SunsetPolicy, statePath string) *SunsetRunner {
+	return &SunsetRunner{
+		List:        list,
+		Policy:      policy,
+		SegmentName: defaultSunsetSegmentName,
+		StatePath:   statePath,
+		Now:         time.Now,
+	}
+}
+
+// LoadState reads the state file, a missing file gives an empty state
+func (runner *SunsetRunner) LoadState() (*SunsetState, error) {
+	state := &SunsetState{ListID: runner.List.ID, Enrolled: make(map[string]*SunsetEnrollment)}
+
+	err := readJSONFile(runner.StatePath, state)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if state.ListID != runner.List.ID {
+		return nil, fmt.Errorf("The sunset state %s is for list %s", runner.StatePath, state.ListID)
+	}
+	if state.Enrolled == nil {
+		state.Enrolled = make(map[string]*SunsetEnrollment)
+	}
+	return state, nil
+}
+
+// Run evaluates every subscribed member: inactive ones are enrolled in the
+// re-engagement segment, enrolled ones who engaged again are removed from it,
+// and the action of the policy is applied to the ones still inactive after
+// the grace period. The state is saved even when the run fails half way.
+func (runner *SunsetRunner) Run() (report *SunsetReport, err error) {
+	if err := runner.List.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+	if err := runner.Policy.validate(); err != nil {
+		return nil, err
+	}
+
+	state, err := runner.LoadState()
+	if err != nil {
+		return nil, err
+	}
+
+	now := runner.now()
+	report = &SunsetReport{DryRun: runner.DryRun, Enrolled: []string{}, Reengaged: []string{}, Sunset: []string{}}
+
+	if !runner.DryRun {
+		defer func() {
+			state.UpdatedAt = now
+			if saveErr := writeJSONFile(runner.StatePath, state); err == nil {
+				err = saveErr
+			}
+		}()
+	}
+
+	enroll := map[string]*SunsetEnrollment{}
+	var reengaged, sunset []*Member
+	seen := map[string]bool{}
+
+	params := &MemberQueryParams{}
+	params.Status = MEMBER_STATUS_SUBSCRIBED
+
+	err = runner.List.EachMember(params, func(member *Member) error {
+		report.Evaluated++
+		seen[member.ID] = true
+
+		feed, err := member.GetAllActivityFeed(MEMBER_ACTIVITY_SENT, MEMBER_ACTIVITY_OPEN, MEMBER_ACTIVITY_CLICK)
+		if err != nil {
+			report.Failures = append(report.Failures, SunsetFailure{EmailAddress: member.EmailAddress, Step: "evaluate", Error: err.Error()})
+			return nil
+		}
+		lastEngaged := feed.LastEngagedAt()
+
+		if enrollment, ok := state.Enrolled[member.ID]; ok {
+			switch {
+			case lastEngaged.After(enrollment.EnrolledAt):
+				reengaged = append(reengaged, member)
+			case now.Sub(enrollment.EnrolledAt) >= runner.Policy.GracePeriod:
+				sunset = append(sunset, member)
+			}
+			return nil
+		}
+
+		optIn, _ := time.Parse(time.RFC3339, member.TimestampOpt)
+		if runner.Policy.Inactive(feed, optIn, now) {
+			enroll[member.ID] = &SunsetEnrollment{
+				EmailAddress:  member.EmailAddress,
+				EnrolledAt:    now,
+				LastEngagedAt: lastEngaged,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	// Enrolled members who are no longer subscribed left on their own
+	for id := range state.Enrolled {
+		if !seen[id] && !runner.DryRun {
+			delete(state.Enrolled, id)
+		}
+	}
+
+	if err := runner.updateSegment(state, enroll, reengaged, report); err != nil {
+		return report, err
+	}
+	runner.sunset(state, sunset, report)
+
+	sort.Strings(report.Enrolled)
+	sort.Strings(report.Reengaged)
+	sort.Strings(report.Sunset)
+	return report, nil
+}
+
+func (runner *SunsetRunner) now() time.Time {
+	if runner.Now == nil {
+		return time.Now()
+	}
+	return runner.Now()
+}
+
+// updateSegment adds the newly inactive members to the re-engagement segment
+// and removes the ones who engaged again, recording both in state.
+func (runner *SunsetRunner) updateSegment(state *SunsetState, enroll map[string]*SunsetEnrollment, reengaged []*Member, report *SunsetReport) error {
+	var toAdd, toRemove []string
+	for _, enrollment := range enroll {
+		toAdd = append(toAdd, enrollment.EmailAddress)
+	}
+	for _, member := range reengaged {
+		toRemove = append(toRemove, member.EmailAddress)
+	}
+
+	if runner.DryRun {
+		report.Enrolled = append(report.Enrolled, toAdd...)
+		report.Reengaged = append(report.Reengaged, toRemove...)
+		return nil
+	}
+	if len(toAdd) == 0 && len(toRemove) == 0 {
+		return nil
+	}
+
+	if state.SegmentID == 0 {
+		segment, err := runner.List.findOrCreateTag(runner.SegmentName)
+		if err != nil {
+			return err
+		}
+		state.SegmentID = segment.ID
+	}
+
+	failed := func(result *BulkTagResult, step string) map[string]bool {
+		emails := map[string]bool{}
+		for _, failure := range result.Failures {
+			emails[failure.EmailAddress] = true
+			report.Failures = append(report.Failures, SunsetFailure{EmailAddress: failure.EmailAddress, Step: step, Error: failure.Error})
+		}
+		return emails
+	}
+
+	added := &BulkTagResult{Tag: runner.SegmentName, SegmentID: state.SegmentID}
+	runner.List.batchModifyTag(added, toAdd, true)
+	addFailures := failed(added, "enroll")
+	for id, enrollment := range enroll {
+		if !addFailures[enrollment.EmailAddress] {
+			state.Enrolled[id] = enrollment
+			report.Enrolled = append(report.Enrolled, enrollment.EmailAddress)
+		}
+	}
+
+	removed := &BulkTagResult{Tag: runner.SegmentName, SegmentID: state.SegmentID}
+	runner.List.batchModifyTag(removed, toRemove, false)
+	removeFailures := failed(removed, "reengage")
+	for _, member := range reengaged {
+		if !removeFailures[member.EmailAddress] {
+			delete(state.Enrolled, member.ID)
+			report.Reengaged = append(report.Reengaged, member.EmailAddress)
+		}
+	}
+
+	return nil
+}
+
+// sunset applies the action of the policy to members past the grace period
+func (runner *SunsetRunner) sunset(state *SunsetState, members []*Member, report *SunsetReport) {
+	for _, member := range members {
+		if runner.DryRun {
+			report.Sunset = append(report.Sunset, member.EmailAddress)
+			continue
+		}
+
+		var err error
+		switch runner.Policy.Action {
+		case SUNSET_ACTION_ARCHIVE:
+			_, err = runner.List.DeleteMember(member.ID)
+		case SUNSET_ACTION_UNSUBSCRIBE:
+			err = runner.List.unsubscribeMember(member.ID)
+		}
+
+		if err != nil {
+			report.Failures = append(report.Failures, SunsetFailure{EmailAddress: member.EmailAddress, Step: runner.Policy.Action, Error: err.Error()})
+			continue
+		}
+		delete(state.Enrolled, member.ID)
+		report.Sunset = append(report.Sunset, member.EmailAddress)
+	}
+}
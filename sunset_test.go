@@ -0,0 +1,117 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSunsetRunner(t *testing.T) {
+	start := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+
+	feeds := map[string]MemberActivityFeed{
+		"ada@example.com": {{ActivityType: MEMBER_ACTIVITY_OPEN, CreatedAtTimestamp: "2019-05-20T10:00:00+00:00"}},
+		"bob@example.com": {{ActivityType: MEMBER_ACTIVITY_OPEN, CreatedAtTimestamp: "2019-01-01T10:00:00+00:00"}},
+		"dan@example.com": {{ActivityType: MEMBER_ACTIVITY_SENT, CreatedAtTimestamp: "2019-02-01T10:00:00+00:00"}},
+	}
+
+	var batches []SegmentBatchRequest
+	var patched []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/members", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, MEMBER_STATUS_SUBSCRIBED, r.URL.Query().Get("status"))
+		members := &ListOfMembers{}
+		for _, email := range []string{"ada@example.com", "bob@example.com", "dan@example.com"} {
+			member := Member{ID: SubscriberHash(email), ListID: "list1"}
+			member.EmailAddress = email
+			member.TimestampOpt = "2018-06-01T00:00:00+00:00"
+			members.Members = append(members.Members, member)
+		}
+		members.TotalItems = len(members.Members)
+		writeJSON(w, members)
+	})
+	for email := range feeds {
+		email := email
+		mux.HandleFunc("/lists/list1/members/"+SubscriberHash(email)+"/activity-feed", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, &ListOfMemberActivityFeed{Activity: feeds[email]})
+		})
+		mux.HandleFunc("/lists/list1/members/"+SubscriberHash(email), func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "PATCH", r.Method)
+			body := map[string]string{}
+			fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, MEMBER_STATUS_UNSUBSCRIBED, body["status"])
+			patched = append(patched, email)
+			writeJSON(w, body)
+		})
+	}
+	mux.HandleFunc("/lists/list1/tag-search", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfTags{})
+	})
+	mux.HandleFunc("/lists/list1/segments", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"id": 42, "name": "Re-engagement", "type": "static"})
+	})
+	mux.HandleFunc("/lists/list1/segments/42", func(w http.ResponseWriter, r *http.Request) {
+		body := SegmentBatchRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		batches = append(batches, body)
+		writeJSON(w, &SegmentBatchResponse{TotalAdded: len(body.MembersToAdd), TotalRemoved: len(body.MembersToRemove)})
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "sunset")
+	fatalIf(t, err)
+	defer os.RemoveAll(dir)
+
+	policy := SunsetPolicy{InactiveDays: 90, GracePeriod: 14 * 24 * time.Hour, Action: SUNSET_ACTION_UNSUBSCRIBE}
+	runner := api.NewListResponse("list1").NewSunsetRunner(policy, filepath.Join(dir, "sunset.json"))
+	runner.Now = func() time.Time { return now }
+
+	runner.DryRun = true
+	report, err := runner.Run()
+	fatalIf(t, err)
+	assert.Equal(t, 3, report.Evaluated)
+	assert.Equal(t, []string{"bob@example.com", "dan@example.com"}, report.Enrolled)
+	assert.Empty(t, batches)
+	_, err = os.Stat(runner.StatePath)
+	assert.True(t, os.IsNotExist(err))
+
+	runner.DryRun = false
+	report, err = runner.Run()
+	fatalIf(t, err)
+	assert.Equal(t, []string{"bob@example.com", "dan@example.com"}, report.Enrolled)
+	if assert.Len(t, batches, 1) {
+		assert.ElementsMatch(t, []string{"bob@example.com", "dan@example.com"}, batches[0].MembersToAdd)
+	}
+
+	state, err := runner.LoadState()
+	fatalIf(t, err)
+	assert.Equal(t, 42, state.SegmentID)
+	assert.Len(t, state.Enrolled, 2)
+
+	now = start.Add(15 * 24 * time.Hour)
+	feeds["dan@example.com"] = append(feeds["dan@example.com"], MemberActivityFeedItem{ActivityType: MEMBER_ACTIVITY_CLICK, CreatedAtTimestamp: "2019-06-03T10:00:00+00:00"})
+
+	report, err = runner.Run()
+	fatalIf(t, err)
+	assert.Empty(t, report.Enrolled)
+	assert.Equal(t, []string{"dan@example.com"}, report.Reengaged)
+	assert.Equal(t, []string{"bob@example.com"}, report.Sunset)
+	assert.Equal(t, []string{"bob@example.com"}, patched)
+	if assert.Len(t, batches, 2) {
+		assert.Equal(t, []string{"dan@example.com"}, batches[1].MembersToRemove)
+	}
+
+	state, err = runner.LoadState()
+	fatalIf(t, err)
+	assert.Empty(t, state.Enrolled)
+}
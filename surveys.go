@@ -0,0 +1,349 @@
+package gochimp3
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	surveys_path              = "/lists/%s/surveys"
+	single_survey_path        = surveys_path + "/%s"
+	survey_publish_path       = single_survey_path + "/actions/publish"
+	survey_unpublish_path     = single_survey_path + "/actions/unpublish"
+	survey_create_email_path  = single_survey_path + "/actions/create-email"
+	survey_reports_path       = "/reporting/surveys"
+	single_survey_report_path = survey_reports_path + "/%s"
+
+	survey_questions_path       = single_survey_report_path + "/questions"
+	single_survey_question_path = survey_questions_path + "/%s"
+	survey_answers_path         = single_survey_question_path + "/answers"
+	survey_responses_path       = single_survey_report_path + "/responses"
+	single_survey_response_path = survey_responses_path + "/%s"
+
+	SURVEY_STATUS_DRAFT       = "draft"
+	SURVEY_STATUS_PUBLISHED   = "published"
+	SURVEY_STATUS_UNPUBLISHED = "unpublished"
+)
+
+type ListOfSurveys struct {
+	baseList
+
+	Surveys []Survey `json:"surveys"`
+}
+
+type Survey struct {
+	ID             string `json:"id"`
+	WebID          int    `json:"web_id"`
+	ListID         string `json:"list_id"`
+	ListName       string `json:"list_name"`
+	Title          string `json:"title"`
+	URL            string `json:"url"`
+	Status         string `json:"status"`
+	PublishedAt    string `json:"published_at"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+	TotalResponses int    `json:"total_responses"`
+
+	withLinks
+	api *API
+}
+
+func (survey *Survey) CanMakeRequest() error {
+	if survey.ListID == "" {
+		return errors.New("No ListID provided on survey")
+	}
+
+	if survey.ID == "" {
+		return errors.New("No ID provided on survey")
+	}
+
+	return nil
+}
+
+func (list *ListResponse) GetSurveys(params *BasicQueryParams) (*ListOfSurveys, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(surveys_path, list.ID)
+	response := new(ListOfSurveys)
+
+	err := list.api.Request("GET", endpoint, params, nil, response)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range response.Surveys {
+		response.Surveys[i].api = list.api
+	}
+
+	return response, nil
+}
+
+func (list *ListResponse) GetSurvey(id string, params *BasicQueryParams) (*Survey, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(single_survey_path, list.ID, id)
+	response := new(Survey)
+	response.api = list.api
+
+	return response, list.api.Request("GET", endpoint, params, nil, response)
+}
+
+// Publish makes the survey available at its URL
+func (survey *Survey) Publish() (*Survey, error) {
+	return survey.action(survey_publish_path)
+}
+
+// Unpublish stops the survey from collecting responses
+func (survey *Survey) Unpublish() (*Survey, error) {
+	return survey.action(survey_unpublish_path)
+}
+
+func (survey *Survey) action(path string) (*Survey, error) {
+	if err := survey.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(path, survey.ListID, survey.ID)
+	response := new(Survey)
+	response.api = survey.api
+
+	return response, survey.api.Request("POST", endpoint, nil, nil, response)
+}
+
+// CreateEmail creates a draft campaign linking to the survey, sent to the
+// list of the survey.
+func (survey *Survey) CreateEmail() (*CampaignResponse, error) {
+	if err := survey.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(survey_create_email_path, survey.ListID, survey.ID)
+	response := new(CampaignResponse)
+	response.api = survey.api
+
+	return response, survey.api.Request("POST", endpoint, nil, nil, response)
+}
+
+// ------------------------------------------------------------------------------------------------
+// Survey Reports
+// ------------------------------------------------------------------------------------------------
+
+type SurveyReport struct {
+	ID             string `json:"id"`
+	WebID          int    `json:"web_id"`
+	ListID         string `json:"list_id"`
+	ListName       string `json:"list_name"`
+	Title          string `json:"title"`
+	URL            string `json:"url"`
+	Status         string `json:"status"`
+	PublishedAt    string `json:"published_at"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+	TotalResponses int    `json:"total_responses"`
+
+	withLinks
+}
+
+type ListOfSurveyQuestionReports struct {
+	baseList
+
+	Questions []SurveyQuestionReport `json:"questions"`
+}
+
+type SurveyQuestionReport struct {
+	ID             string                 `json:"id"`
+	SurveyID       string                 `json:"survey_id"`
+	Query          string                 `json:"query"`
+	Type           string                 `json:"type"`
+	HasOther       bool                   `json:"has_other"`
+	OtherLabel     string                 `json:"other_label"`
+	TotalResponses int                    `json:"total_responses"`
+	MergeField     SurveyMergeField       `json:"merge_field"`
+	Options        []SurveyQuestionOption `json:"options"`
+	AverageRating  float64                `json:"average_rating"`
+
+	withLinks
+}
+
+// SurveyMergeField is the merge field updated by the answers to a question
+type SurveyMergeField struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+}
+
+type SurveyQuestionOption struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+type SurveyContact struct {
+	EmailID                     string `json:"email_id"`
+	ContactID                   string `json:"contact_id"`
+	Status                      string `json:"status"`
+	Email                       string `json:"email"`
+	FullName                    string `json:"full_name"`
+	ConsentsToOneToOneMessaging bool   `json:"consents_to_one_to_one_messaging"`
+	AvatarURL                   string `json:"avatar_url"`
+}
+
+type ListOfSurveyAnswers struct {
+	baseList
+
+	Answers []SurveyAnswer `json:"answers"`
+}
+
+type SurveyAnswer struct {
+	ID           string        `json:"id"`
+	Value        string        `json:"value"`
+	ResponseID   string        `json:"response_id"`
+	SubmittedAt  string        `json:"submitted_at"`
+	Contact      SurveyContact `json:"contact"`
+	IsNewContact bool          `json:"is_new_contact"`
+
+	withLinks
+}
+
+type ListOfSurveyResponses struct {
+	baseList
+
+	Responses []SurveyResponse `json:"responses"`
+}
+
+// SurveyResponse is the submission of a contact, Results are only set when
+// getting a single response.
+type SurveyResponse struct {
+	ResponseID   string         `json:"response_id"`
+	SubmittedAt  string         `json:"submitted_at"`
+	Contact      SurveyContact  `json:"contact"`
+	IsNewContact bool           `json:"is_new_contact"`
+	SurveyID     string         `json:"survey_id"`
+	SurveyTitle  string         `json:"survey_title"`
+	Results      []SurveyResult `json:"results"`
+
+	withLinks
+}
+
+type SurveyResult struct {
+	QuestionID    string `json:"question_id"`
+	QuestionLabel string `json:"question_label"`
+	QuestionType  string `json:"question_type"`
+	Answer        string `json:"answer"`
+}
+
+type SurveyResponsesQueryParams struct {
+	ExtendedQueryParams
+
+	// ChosenAnswer and QuestionID only return the responses choosing an
+	// option of a question
+	ChosenAnswer string
+	QuestionID   string
+
+	// RespondentFamiliarityIs is one of "new", "known" or "unknown"
+	RespondentFamiliarityIs string
+}
+
+func (q *SurveyResponsesQueryParams) Params() map[string]string {
+	m := q.ExtendedQueryParams.Params()
+	m["chosen_answer"] = q.ChosenAnswer
+	m["question_id"] = q.QuestionID
+	m["respondent_familiarity_is"] = q.RespondentFamiliarityIs
+	return m
+}
+
+func (list *ListResponse) GetSurveyReport(surveyID string, params *BasicQueryParams) (*SurveyReport, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(single_survey_report_path, surveyID)
+	response := new(SurveyReport)
+
+	return response, list.api.Request("GET", endpoint, params, nil, response)
+}
+
+func (list *ListResponse) GetSurveyQuestionReports(surveyID string, params *BasicQueryParams) (*ListOfSurveyQuestionReports, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(survey_questions_path, surveyID)
+	response := new(ListOfSurveyQuestionReports)
+
+	return response, list.api.Request("GET", endpoint, params, nil, response)
+}
+
+func (list *ListResponse) GetSurveyQuestionReport(surveyID, questionID string, params *BasicQueryParams) (*SurveyQuestionReport, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(single_survey_question_path, surveyID, questionID)
+	response := new(SurveyQuestionReport)
+
+	return response, list.api.Request("GET", endpoint, params, nil, response)
+}
+
+func (list *ListResponse) GetSurveyAnswers(surveyID, questionID string, params *SurveyResponsesQueryParams) (*ListOfSurveyAnswers, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(survey_answers_path, surveyID, questionID)
+	response := new(ListOfSurveyAnswers)
+
+	return response, list.api.Request("GET", endpoint, params, nil, response)
+}
+
+func (list *ListResponse) GetSurveyResponses(surveyID string, params *SurveyResponsesQueryParams) (*ListOfSurveyResponses, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(survey_responses_path, surveyID)
+	response := new(ListOfSurveyResponses)
+
+	return response, list.api.Request("GET", endpoint, params, nil, response)
+}
+
+func (list *ListResponse) GetSurveyResponse(surveyID, responseID string) (*SurveyResponse, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(single_survey_response_path, surveyID, responseID)
+	response := new(SurveyResponse)
+
+	return response, list.api.Request("GET", endpoint, nil, nil, response)
+}
+
+// GetAllSurveyResponses pages through the responses of a survey, matching
+// params when set.
+func (list *ListResponse) GetAllSurveyResponses(surveyID string, params *SurveyResponsesQueryParams) ([]SurveyResponse, error) {
+	query := SurveyResponsesQueryParams{}
+	if params != nil {
+		query = *params
+	}
+	query.Count = maxPageSize
+	query.Offset = 0
+
+	var responses []SurveyResponse
+	err := pageThrough(&query.ExtendedQueryParams, func() (int, int, error) {
+		page, err := list.GetSurveyResponses(surveyID, &query)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		responses = append(responses, page.Responses...)
+		return len(page.Responses), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
@@ -0,0 +1,63 @@
+package gochimp3
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSurveys(t *testing.T) {
+	var offsets []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/locations", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfLocations{Locations: []Location{{Country: "France", CC: "FR", Percent: 62.5, Total: 5}}})
+	})
+	mux.HandleFunc("/lists/list1/surveys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfSurveys{Surveys: []Survey{{ID: "s1", ListID: "list1", Status: SURVEY_STATUS_DRAFT}}})
+	})
+	mux.HandleFunc("/lists/list1/surveys/s1/actions/publish", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		writeJSON(w, &Survey{ID: "s1", ListID: "list1", Status: SURVEY_STATUS_PUBLISHED})
+	})
+	mux.HandleFunc("/lists/list1/surveys/s1/actions/create-email", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		writeJSON(w, map[string]string{"id": "c1"})
+	})
+	mux.HandleFunc("/reporting/surveys/s1/responses", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "q1", r.URL.Query().Get("question_id"))
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		responses := &ListOfSurveyResponses{Responses: []SurveyResponse{{ResponseID: "r1"}}}
+		responses.TotalItems = 1
+		writeJSON(w, responses)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+	list := api.NewListResponse("list1")
+
+	locations, err := list.GetLocations(nil)
+	fatalIf(t, err)
+	assert.Equal(t, []Location{{Country: "France", CC: "FR", Percent: 62.5, Total: 5}}, locations.Locations)
+
+	surveys, err := list.GetSurveys(nil)
+	fatalIf(t, err)
+	survey, err := surveys.Surveys[0].Publish()
+	fatalIf(t, err)
+	assert.Equal(t, SURVEY_STATUS_PUBLISHED, survey.Status)
+
+	campaign, err := survey.CreateEmail()
+	fatalIf(t, err)
+	assert.Equal(t, "c1", campaign.ID)
+
+	_, err = (&Survey{ID: "s1"}).Unpublish()
+	assert.Error(t, err)
+
+	params := &SurveyResponsesQueryParams{QuestionID: "q1"}
+	params.Offset = 10
+	responses, err := list.GetAllSurveyResponses("s1", params)
+	fatalIf(t, err)
+	assert.Equal(t, []SurveyResponse{{ResponseID: "r1"}}, responses)
+	assert.Equal(t, []string{"0"}, offsets)
+}
@@ -0,0 +1,300 @@
+package gochimp3
+
+import (
+	"fmt"
+)
+
+const (
+	tag_search_path = "/lists/%s/tag-search"
+
+	// Mailchimp accepts at most 500 emails per static segment batch modification
+	maxSegmentBatchSize = 500
+)
+
+type TagSearchQueryParams struct {
+	BasicQueryParams
+
+	Name string
+}
+
+func (q *TagSearchQueryParams) Params() map[string]string {
+	m := q.BasicQueryParams.Params()
+	m["name"] = q.Name
+	return m
+}
+
+type ListOfTags struct {
+	Tags       []ListTag `json:"tags"`
+	TotalItems int       `json:"total_items"`
+}
+
+// ListTag is a tag of a list. Tags are static segments, ID is the ID of the
+// segment.
+type ListTag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+
+	// MemberCount is only set when the tag is read from its static segment,
+	// see GetTagsWithCounts
+	MemberCount int `json:"member_count,omitempty"`
+}
+
+// SearchTags returns the tags of the list whose name matches params.Name, all
+// of them if it is empty.
+func (list *ListResponse) SearchTags(params *TagSearchQueryParams) (*ListOfTags, error) {
+	if err := list.CanMakeRequest(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(tag_search_path, list.ID)
+	response := new(ListOfTags)
+
+	return response, list.api.Request("GET", endpoint, params, nil, response)
+}
+
+// FindTag returns the tag with exactly the given name, or nil when the list
+// has no such tag. The tag search is not paged, when it matches more tags than
+// it returns without the exact name, the tags are read from the static
+// segments backing them instead.
+func (list *ListResponse) FindTag(name string) (*ListTag, error) {
+	response, err := list.SearchTags(&TagSearchQueryParams{Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := response.Tags
+	if findTag(tags, name) == nil && response.TotalItems > len(response.Tags) {
+		if tags, err = list.GetTagsWithCounts(); err != nil {
+			return nil, err
+		}
+	}
+	return findTag(tags, name), nil
+}
+
+func findTag(tags []ListTag, name string) *ListTag {
+	for i := range tags {
+		if tags[i].Name == name {
+			return &tags[i]
+		}
+	}
+	return nil
+}
+
+// GetTagsWithCounts returns every tag of the list with its member count, read
+// from the static segments backing the tags, which unlike SearchTags are
+// paged.
+func (list *ListResponse) GetTagsWithCounts() ([]ListTag, error) {
+	params := &SegmentQueryParams{Type: SEGMENT_TYPE_STATIC}
+
+	tags := []ListTag{}
+	err := pageThrough(&params.ExtendedQueryParams, func() (int, int, error) {
+		page, err := list.GetSegments(params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for _, segment := range page.Segments {
+			tags = append(tags, ListTag{ID: segment.ID, Name: segment.Name, MemberCount: segment.MemberCount})
+		}
+		return len(page.Segments), page.TotalItems, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// RenameTag renames a tag, keeping its members. It fails when the list has no
+// tag named from or already has a tag named to.
+func (list *ListResponse) RenameTag(from, to string) (*ListTag, error) {
+	found, err := list.FindTag(from)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("No tag %q on list %s", from, list.ID)
+	}
+
+	existing, err := list.FindTag(to)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("Tag %q already exists on list %s", to, list.ID)
+	}
+
+	// Only the name is sent, an empty static_segment would untag every member
+	endpoint := fmt.Sprintf(single_segment_path, list.ID, found.ID)
+	body := map[string]string{"name": to}
+	response := new(Segment)
+	if err := list.api.Request("PATCH", endpoint, nil, body, response); err != nil {
+		return nil, err
+	}
+
+	return &ListTag{ID: response.ID, Name: response.Name}, nil
+}
+
+// DeleteTag deletes a tag, removing it from every member. Nothing is done if
+// the list has no such tag.
+func (list *ListResponse) DeleteTag(name string) (bool, error) {
+	found, err := list.FindTag(name)
+	if err != nil || found == nil {
+		return false, err
+	}
+
+	return list.DeleteSegment(found.ID)
+}
+
+// MergeTags applies the tag into, created when needed, to every member tagged
+// from and then deletes from. When some members could not be tagged from is
+// kept and an error is returned along with the result.
+func (list *ListResponse) MergeTags(from, into string) (*BulkTagResult, error) {
+	if from == into {
+		return nil, fmt.Errorf("Can not merge tag %q into itself", from)
+	}
+
+	source, err := list.FindTag(from)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("No tag %q on list %s", from, list.ID)
+	}
+
+	segment := &Segment{ID: source.ID, ListID: list.ID, api: list.api}
+	params := &SegmentMemberQueryParams{IncludeCleaned: true, IncludeTransactional: true, IncludeUnsubscribed: true}
+	params.Fields = []string{"total_items", "members.email_address"}
+
+	var emails []string
+	err = segment.EachMember(params, func(member *Member) error {
+		emails = append(emails, member.EmailAddress)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := list.findOrCreateTag(into)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkTagResult{Tag: into, SegmentID: destination.ID}
+	list.batchModifyTag(result, emails, true)
+	if len(result.Failures) > 0 {
+		return result, fmt.Errorf("Kept tag %q, %d of its members could not be tagged %q", from, len(result.Failures), into)
+	}
+
+	if _, err := list.DeleteSegment(source.ID); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// Bulk tagging
+// ------------------------------------------------------------------------------------------------
+
+// TagFailure is an email which could not be tagged or untagged
+type TagFailure struct {
+	EmailAddress string `json:"email_address"`
+	Error        string `json:"error"`
+}
+
+// BulkTagResult reports the outcome of tagging or untagging many members
+type BulkTagResult struct {
+	Tag       string       `json:"tag"`
+	SegmentID int          `json:"segment_id"`
+	Added     int          `json:"added"`
+	Removed   int          `json:"removed"`
+	Failures  []TagFailure `json:"failures,omitempty"`
+}
+
+// AddTagToMembers applies the tag to every member of the list in emails,
+// creating the tag when needed. The emails are sent to the static segment
+// behind the tag in batches of 500. Emails which could not be tagged, e.g.
+// because they are not on the list, are reported in the result and do not
+// stop the other batches.
+func (list *ListResponse) AddTagToMembers(tag string, emails []string) (*BulkTagResult, error) {
+	found, err := list.findOrCreateTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkTagResult{Tag: tag, SegmentID: found.ID}
+	list.batchModifyTag(result, emails, true)
+	return result, nil
+}
+
+// findOrCreateTag returns the tag with the given name, creating the static
+// segment backing it if needed.
+func (list *ListResponse) findOrCreateTag(name string) (*ListTag, error) {
+	found, err := list.FindTag(name)
+	if err != nil || found != nil {
+		return found, err
+	}
+
+	segment, err := list.CreateSegment(&SegmentRequest{Name: name, StaticSegment: []string{}})
+	if err != nil {
+		return nil, err
+	}
+	return &ListTag{ID: segment.ID, Name: segment.Name}, nil
+}
+
+// RemoveTagFromMembers removes the tag from every member of the list in
+// emails, see AddTagToMembers. Nothing is done if the list has no such tag.
+func (list *ListResponse) RemoveTagFromMembers(tag string, emails []string) (*BulkTagResult, error) {
+	found, err := list.FindTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkTagResult{Tag: tag}
+	if found == nil {
+		return result, nil
+	}
+
+	result.SegmentID = found.ID
+	list.batchModifyTag(result, emails, false)
+	return result, nil
+}
+
+func (list *ListResponse) batchModifyTag(result *BulkTagResult, emails []string, add bool) {
+	for _, chunk := range chunkStrings(emails, maxSegmentBatchSize) {
+		body := &SegmentBatchRequest{MembersToAdd: []string{}, MembersToRemove: []string{}}
+		if add {
+			body.MembersToAdd = chunk
+		} else {
+			body.MembersToRemove = chunk
+		}
+
+		response, err := list.BatchModifySegment(result.SegmentID, body)
+		if err != nil {
+			for _, email := range chunk {
+				result.Failures = append(result.Failures, TagFailure{EmailAddress: email, Error: err.Error()})
+			}
+			continue
+		}
+
+		result.Added += response.TotalAdded
+		result.Removed += response.TotalRemoved
+		for _, batchErr := range response.Errors {
+			for _, email := range batchErr.EmailAddresses {
+				result.Failures = append(result.Failures, TagFailure{EmailAddress: email, Error: batchErr.Error})
+			}
+		}
+	}
+}
+
+// chunkStrings splits s in slices of at most size elements
+func chunkStrings(s []string, size int) [][]string {
+	var chunks [][]string
+	for len(s) > size {
+		chunks = append(chunks, s[:size:size])
+		s = s[size:]
+	}
+	if len(s) > 0 {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
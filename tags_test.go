@@ -0,0 +1,161 @@
+package gochimp3
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTagToMembers(t *testing.T) {
+	var batches []SegmentBatchRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/tag-search", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "launch", r.URL.Query().Get("name"))
+		writeJSON(w, &ListOfTags{Tags: []ListTag{{ID: 7, Name: "launch-beta"}}, TotalItems: 1})
+	})
+	mux.HandleFunc("/lists/list1/segments", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		writeJSON(w, map[string]interface{}{"id": 42, "name": "launch", "type": "static"})
+	})
+	mux.HandleFunc("/lists/list1/segments/42", func(w http.ResponseWriter, r *http.Request) {
+		body := SegmentBatchRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		batches = append(batches, body)
+
+		response := &SegmentBatchResponse{TotalAdded: len(body.MembersToAdd)}
+		if len(batches) == 3 {
+			response.TotalAdded--
+			response.Errors = []SegmentBatchError{{EmailAddresses: []string{body.MembersToAdd[0]}, Error: "not a member"}}
+		}
+		writeJSON(w, response)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	emails := make([]string, 1200)
+	for i := range emails {
+		emails[i] = "user" + strconv.Itoa(i) + "@example.com"
+	}
+
+	result, err := api.NewListResponse("list1").AddTagToMembers("launch", emails)
+	fatalIf(t, err)
+
+	assert.Equal(t, 42, result.SegmentID)
+	assert.Equal(t, 1199, result.Added)
+	assert.Equal(t, []TagFailure{{EmailAddress: "user1000@example.com", Error: "not a member"}}, result.Failures)
+	if assert.Len(t, batches, 3) {
+		assert.Len(t, batches[0].MembersToAdd, 500)
+		assert.Len(t, batches[2].MembersToAdd, 200)
+		assert.NotNil(t, batches[2].MembersToRemove)
+	}
+}
+
+func TestTagManagement(t *testing.T) {
+	tags := map[string]int{"old": 7, "vip": 8}
+	var requests []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/tag-search", func(w http.ResponseWriter, r *http.Request) {
+		found := &ListOfTags{Tags: []ListTag{}}
+		if id, ok := tags[r.URL.Query().Get("name")]; ok {
+			found.Tags = append(found.Tags, ListTag{ID: id, Name: r.URL.Query().Get("name")})
+		}
+		writeJSON(w, found)
+	})
+	mux.HandleFunc("/lists/list1/segments", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "static", r.URL.Query().Get("type"))
+		segments := &ListOfSegments{Segments: []Segment{{ID: 7, MemberCount: 2}, {ID: 8, MemberCount: 5}}}
+		segments.Segments[0].Name = "old"
+		segments.Segments[1].Name = "vip"
+		segments.TotalItems = 2
+		writeJSON(w, segments)
+	})
+	mux.HandleFunc("/lists/list1/segments/7", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PATCH":
+			body := map[string]interface{}{}
+			fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, map[string]interface{}{"name": "legacy"}, body)
+			writeJSON(w, map[string]interface{}{"id": 7, "name": "legacy"})
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+		requests = append(requests, r.Method+" 7")
+	})
+	mux.HandleFunc("/lists/list1/segments/7/members", func(w http.ResponseWriter, r *http.Request) {
+		page := &ListOfMembers{Members: []Member{{}}}
+		page.Members[0].EmailAddress = "ada@example.com"
+		page.TotalItems = 1
+		writeJSON(w, page)
+	})
+	mux.HandleFunc("/lists/list1/segments/8", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" 8")
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body := SegmentBatchRequest{}
+		fatalIf(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"ada@example.com"}, body.MembersToAdd)
+		writeJSON(w, &SegmentBatchResponse{TotalAdded: 1})
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+	list := api.NewListResponse("list1")
+
+	counts, err := list.GetTagsWithCounts()
+	fatalIf(t, err)
+	assert.Equal(t, []ListTag{{ID: 7, Name: "old", MemberCount: 2}, {ID: 8, Name: "vip", MemberCount: 5}}, counts)
+
+	renamed, err := list.RenameTag("old", "legacy")
+	fatalIf(t, err)
+	assert.Equal(t, &ListTag{ID: 7, Name: "legacy"}, renamed)
+	_, err = list.RenameTag("old", "vip")
+	assert.Error(t, err)
+	_, err = list.RenameTag("missing", "other")
+	assert.Error(t, err)
+
+	deleted, err := list.DeleteTag("missing")
+	fatalIf(t, err)
+	assert.False(t, deleted)
+
+	result, err := list.MergeTags("old", "vip")
+	fatalIf(t, err)
+	assert.Equal(t, 1, result.Added)
+	assert.Equal(t, []string{"PATCH 7", "POST 8", "DELETE 7"}, requests)
+
+	deleted, err = list.DeleteTag("vip")
+	fatalIf(t, err)
+	assert.True(t, deleted)
+	assert.Equal(t, "DELETE 8", requests[len(requests)-1])
+}
+
+func TestFindTagBeyondSearchResults(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lists/list1/tag-search", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &ListOfTags{Tags: []ListTag{{ID: 7, Name: "vip-old"}}, TotalItems: 2})
+	})
+	mux.HandleFunc("/lists/list1/segments", func(w http.ResponseWriter, r *http.Request) {
+		segments := &ListOfSegments{Segments: []Segment{{ID: 7}, {ID: 8, MemberCount: 5}}}
+		segments.Segments[0].Name = "vip-old"
+		segments.Segments[1].Name = "vip"
+		segments.TotalItems = 2
+		writeJSON(w, segments)
+	})
+
+	api, server := testAPIServer(mux)
+	defer server.Close()
+
+	tag, err := api.NewListResponse("list1").FindTag("vip")
+	fatalIf(t, err)
+	assert.Equal(t, &ListTag{ID: 8, Name: "vip", MemberCount: 5}, tag)
+
+	tag, err = api.NewListResponse("list1").FindTag("missing")
+	fatalIf(t, err)
+	assert.Nil(t, tag)
+}